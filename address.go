@@ -9,8 +9,6 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
-
-	"golang.org/x/crypto/blake2b"
 )
 
 //some len const
@@ -150,8 +148,7 @@ func EncodeAddress(prefix uint8, hexed string) (string, error) {
 	if len(hexed) != 64 {
 		return "", errors.New("invalid address len, should be 64")
 	}
-	ui := uint256SetHex(hexed)
-	return strconv.Itoa(int(prefix)) + Base32Encode(ui[:]), nil
+	return string(AppendAddress(make([]byte, 0, 57), prefix, uint256SetHex(hexed))), nil
 }
 
 // ConvertAddress2pubk .
@@ -232,8 +229,12 @@ type CDestination struct {
 }
 
 func (a CDestination) String() string {
-	add, _ := EncodeAddress(a.Prefix, hex.EncodeToString(CopyReverse(a.Data[:])))
-	return add
+	return string(a.AppendAddress(make([]byte, 0, 57)))
+}
+
+// AppendAddress 将地址追加到dst
+func (a CDestination) AppendAddress(dst []byte) []byte {
+	return AppendAddress(dst, a.Prefix, a.Data)
 }
 
 type VoteTpl struct {
@@ -252,10 +253,16 @@ type DexOrderParam struct {
 	MatchAddress  Address `json:"match_address"`
 	DealAddress   string  `json:"deal_address"`
 	Timestamp     uint32  `json:"timestamp"`
+	NoTimestamp   bool    `json:"-"` //旧版core的模版数据不包含 timestamp, ParseDexOrderTemplate 时记录, 重新编码时得到相同的地址
 }
 
 // CreateTemplateDataDexOrder return tplID, tplData, error
+// Price, Fee 的精度参考 DexOrderPriceScale, DexOrderFeeScale, 也可以使用 ParseDexOrderJSON 从core格式的参数构造;
+// 除地址外不检查参数(与之前相同), 需要时先调用 p.Validate()
 func CreateTemplateDataDexOrder(p DexOrderParam) (string, string, error) {
+	if p.NoTimestamp && p.Timestamp != 0 {
+		return "", "", errors.New("timestamp not allowed in legacy dexorder template")
+	}
 	buf := bytes.NewBuffer(nil)
 	var errs []error
 
@@ -292,15 +299,14 @@ func CreateTemplateDataDexOrder(p DexOrderParam) (string, string, error) {
 	write(p.ValidHeight)
 	writeAddress(p.MatchAddress)
 	writeString(p.DealAddress)
-	write(p.Timestamp)
+	if !p.NoTimestamp {
+		write(p.Timestamp)
+	}
 	if len(errs) != 0 {
 		return "", "", fmt.Errorf("some errors when write binary: %v", errs)
 	}
-	hash := blake2b.Sum256(buf.Bytes()[2:]) //remove type
-	x := make([]byte, 2)
-	binary.LittleEndian.PutUint16(x, templateDexorder)
-	x = append(x, hash[:len(hash)-2]...)
-	return string(AddressPrefixTpl) + Base32Encode(x[:]), hex.EncodeToString(buf.Bytes()), nil
+	addr := templateDestination(templateDexorder, buf.Bytes()[2:]) //remove type
+	return addr.String(), hex.EncodeToString(buf.Bytes()), nil
 }
 
 // GetAddressBytes prefix, pubkOrHash, error
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -83,7 +84,6 @@ func TestTemplateAddr(t *testing.T) {
 }
 
 func TestCreateTemplateDataDexOrder(t *testing.T) {
-	t.Skip("这个测试跳过，后来改了字段，测试用例没改，需要修复这个测试")
 	/**
 	bigbang> addnewtemplate dexorder '{"seller_address":"1jv78wjv22hmzcwv07bkkphnkj51y0kjc7g9rwdm05erwmr2n8tvh8yjn","coinpair":"bbc/mkf","price":10,"fee": 0.002,"recv_address":"1jv78wjv22hmzcwv07bkkphnkj51y0kjc7g9rwdm05erwmr2n8tvh8yjn","valid_height": 300,"match_address": "15cx56x0gtv44bkt21yryg4m6nn81wtc7gkf6c9vwpvq1cgmm8jm7m5kd","deal_address": "1f2b2n3asbm2rb99fk1c4wp069d0z91enxdz8kmqmq7f0w8tzw64hdevb"}'
 	2140cp9r0rchawvvcvbtkxe440h844xx4h8h1hbcwdpd4tqtcxnjy1vqv
@@ -113,8 +113,12 @@ func TestCreateTemplateDataDexOrder(t *testing.T) {
 	}
 	*/
 
-	tw := TW{T: t}
-	add, data, err := CreateTemplateDataDexOrder(DexOrderParam{
+	// 以上为不包含timestamp的core版本的输出, 当前core在模版数据最后增加了 timestamp(uint32),
+	// 当前格式与core的比较见 TestDexOrderCoreVector
+	const coreJSON = `{"seller_address":"1jv78wjv22hmzcwv07bkkphnkj51y0kjc7g9rwdm05erwmr2n8tvh8yjn","coinpair":"bbc/mkf","price":10,"fee": 0.002,"recv_address":"1jv78wjv22hmzcwv07bkkphnkj51y0kjc7g9rwdm05erwmr2n8tvh8yjn","valid_height": 300,"match_address": "15cx56x0gtv44bkt21yryg4m6nn81wtc7gkf6c9vwpvq1cgmm8jm7m5kd","deal_address": "1f2b2n3asbm2rb99fk1c4wp069d0z91enxdz8kmqmq7f0w8tzw64hdevb"}`
+	const coreHex = "09000196ce8e4b621469f673603ae73b46b39143e04e4c3c138e36802bb1ca605546b707000000000000006262632f6d6b6600e8764817000000140000003900000000000000316a763738776a763232686d7a6377763037626b6b70686e6b6a353179306b6a633767397277646d30356572776d72326e3874766838796a6e2c010000012b3a537410d6c845cf420fb1e81286ad501e698784de66277cb6ee16429444a8390000000000000031663262326e336173626d3272623939666b316334777030363964307a3931656e78647a386b6d716d713766307738747a7736346864657662"
+	const coreAddress = "2140cp9r0rchawvvcvbtkxe440h844xx4h8h1hbcwdpd4tqtcxnjy1vqv"
+	expected := DexOrderParam{
 		SellerAddress: "1jv78wjv22hmzcwv07bkkphnkj51y0kjc7g9rwdm05erwmr2n8tvh8yjn",
 		Coinpair:      "bbc/mkf",
 		Price:         10_0_000_000_000, //10
@@ -123,13 +127,65 @@ func TestCreateTemplateDataDexOrder(t *testing.T) {
 		ValidHeight:   300,
 		MatchAddress:  "15cx56x0gtv44bkt21yryg4m6nn81wtc7gkf6c9vwpvq1cgmm8jm7m5kd",
 		DealAddress:   "1f2b2n3asbm2rb99fk1c4wp069d0z91enxdz8kmqmq7f0w8tzw64hdevb",
-	})
+	}
+
+	tw := TW{T: t}
+	add, err := TemplateAddress(coreHex)
+	tw.Nil(err).Equal(coreAddress, add)
+	parsed, err := ParseDexOrderTemplate(coreHex)
+	tw.Nil(err).True(parsed.NoTimestamp)
+	//重新编码保持旧格式, 得到core给出的地址
+	add, data, err := CreateTemplateDataDexOrder(*parsed)
+	tw.Nil(err).Equal(coreHex, data).Equal(coreAddress, add)
+	parsed.NoTimestamp = false
+	tw.Equal(expected, *parsed)
+	p, err := ParseDexOrderJSON([]byte(coreJSON))
+	tw.Nil(err).Equal(expected, p)
+	tw.Equal("10", p.JSON().Price.String()).Equal("0.002", p.JSON().Fee.String())
+
+	add, data, err = CreateTemplateDataDexOrder(expected)
+	tw.Nil(err)
+	tw.Equal(coreHex+"00000000", data)
+	tplAdd, err := TemplateAddress(data)
+	tw.Nil(err).Equal(tplAdd, add)
+	// 参数检查需要单独调用 Validate
+	unchecked := expected
+	unchecked.Coinpair, unchecked.RecvAddress = "bbc-mkf", ""
+	_, _, err = CreateTemplateDataDexOrder(unchecked)
+	tw.Nil(err).True(unchecked.Validate() != nil)
+	legacy := expected
+	legacy.NoTimestamp, legacy.Timestamp = true, 1
+	_, _, err = CreateTemplateDataDexOrder(legacy)
+	tw.True(err != nil, "timestamp in legacy format")
+
+	expected.Timestamp = 1590474715
+	add, data, err = CreateTemplateDataDexOrder(expected)
+	tw.Nil(err)
+	parsed, err = ParseDexOrderTemplate(data)
+	tw.Nil(err).Equal(expected, *parsed)
+	jsonParam, err := ParseDexOrderJSON([]byte(strings.Replace(coreJSON, "}", `,"timestamp":1590474715}`, 1)))
 	tw.Nil(err)
-	tw.Equal(
-		"09000196ce8e4b621469f673603ae73b46b39143e04e4c3c138e36802bb1ca605546b707000000000000006262632f6d6b6600e8764817000000140000003900000000000000316a763738776a763232686d7a6377763037626b6b70686e6b6a353179306b6a633767397277646d30356572776d72326e3874766838796a6e2c010000012b3a537410d6c845cf420fb1e81286ad501e698784de66277cb6ee16429444a8390000000000000031663262326e336173626d3272623939666b316334777030363964307a3931656e78647a386b6d716d713766307738747a7736346864657662",
-		data,
-	)
-	tw.Equal("2140cp9r0rchawvvcvbtkxe440h844xx4h8h1hbcwdpd4tqtcxnjy1vqv", add)
+	jsonAdd, _, err := CreateTemplateDataDexOrder(jsonParam)
+	tw.Nil(err).Equal(add, jsonAdd)
+
+	_, err = ParseDexOrderTemplate(data + "00")
+	tw.True(err != nil, "trailing")
+	_, err = ParseDexOrderTemplate("0200" + data[4:])
+	tw.True(err != nil, "type")
+
+	for _, j := range []string{
+		strings.Replace(coreJSON, "bbc/mkf", "bbcmkf", 1),
+		strings.Replace(coreJSON, "bbc/mkf", "bbc/BBC", 1),
+		strings.Replace(coreJSON, "bbc/mkf", "bbc/m$f", 1),
+		strings.Replace(coreJSON, `"price":10`, `"price":0.00000000001`, 1),
+		strings.Replace(coreJSON, `"price":10`, `"price":0`, 1),
+		strings.Replace(coreJSON, `0.002`, `0.00025`, 1),
+		strings.Replace(coreJSON, `0.002`, `1`, 1),
+		strings.Replace(coreJSON, `15cx56`, `15cx57`, 1),
+	} {
+		_, err = ParseDexOrderJSON([]byte(j))
+		tw.True(err != nil, j)
+	}
 }
 
 func TestPrice(t *testing.T) {
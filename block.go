@@ -0,0 +1,242 @@
+package gobbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// maxBlockSize 严格模式下区块中变长字段(proof, vtx数量, sig)的上限, 参考core MAX_BLOCK_SIZE
+const maxBlockSize = 2000000
+
+// Block 区块, 序列化结构参考core CBlock:
+// nVersion, nType, nTimeStamp, hashPrev, hashMerkle, vchProof, txMint, vtx, vchSig
+type Block struct {
+	Version    uint16
+	Type       BlockType
+	Timestamp  uint32
+	HashPrev   [32]byte         //内存字节序, hex显示时需要反转, 参考 PrevHash
+	HashMerkle [32]byte         //交易merkle root, 内存字节序
+	Proof      []byte           //vchProof (pow/dpos 证明数据)
+	TxMint     RawTransaction   //铸币tx
+	Vtx        []RawTransaction //区块中的其他tx
+	Sig        []byte           //vchSig 区块签名
+
+	serializer Serializer //tx 序列化器
+}
+
+// NewBlock 创建空区块, serializer 用于区块中tx的序列化
+func NewBlock(serializer Serializer) *Block {
+	return &Block{serializer: serializer}
+}
+
+// DecodeBlock 解析区块hex数据, serializer: BBCSerializer, BBCStrictSerializer, *ChainParams 等本包提供的 Serializer,
+// 严格模式下存在多余的尾部数据时返回错误
+func DecodeBlock(serializer Serializer, blockData string) (*Block, error) {
+	b, err := hex.DecodeString(blockData)
+	if err != nil {
+		return nil, fmt.Errorf("block data hex decode failed, %v", err)
+	}
+	r := bytes.NewReader(b)
+	d := NewDecoder(r, serializer)
+	block, err := d.DecodeBlock()
+	if err == io.EOF {
+		err = d.fail("Block.Version", io.ErrUnexpectedEOF)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if d.s.limits != nil && r.Len() > 0 {
+		return nil, &DecodeError{Field: "EOF", Offset: int(d.off), Err: ErrTrailingData}
+	}
+	return block, nil
+}
+
+// DecodeBlock 读取下一个区块, 数据流在区块边界结束时返回 io.EOF
+func (d *Decoder) DecodeBlock() (*Block, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	block := &Block{serializer: d.s}
+	n, err := io.ReadFull(d.r, d.buf[:2])
+	if err == io.EOF { //正好在区块边界结束
+		return nil, io.EOF
+	} else if err != nil {
+		return nil, d.fail("Block.Version", err)
+	}
+	d.off += int64(n)
+	block.Version = binary.LittleEndian.Uint16(d.buf[:])
+
+	var b []byte
+	if b, err = d.read("Block.Type", 2); err != nil {
+		return nil, err
+	}
+	block.Type = BlockType(binary.LittleEndian.Uint16(b))
+	if b, err = d.read("Block.Timestamp", 4); err != nil {
+		return nil, err
+	}
+	block.Timestamp = binary.LittleEndian.Uint32(b)
+	if _, err = d.readFull("Block.HashPrev", block.HashPrev[:]); err != nil {
+		return nil, err
+	}
+	if _, err = d.readFull("Block.HashMerkle", block.HashMerkle[:]); err != nil {
+		return nil, err
+	}
+	var size uint64
+	if size, err = d.readSize("Block.Proof", maxBlockSize); err != nil {
+		return nil, err
+	}
+	if block.Proof, err = d.readBytes("Block.Proof", size, 1); err != nil {
+		return nil, err
+	}
+	if block.TxMint, err = d.decodeBlockTx("Block.TxMint"); err != nil {
+		return nil, err
+	}
+	if size, err = d.readSize("Block.Vtx", maxBlockSize); err != nil {
+		return nil, err
+	}
+	for i := uint64(0); i < size; i++ {
+		tx, err := d.decodeBlockTx(fmt.Sprintf("Block.Vtx[%d]", i))
+		if err != nil {
+			return nil, err
+		}
+		block.Vtx = append(block.Vtx, tx)
+	}
+	if size, err = d.readSize("Block.Sig", maxBlockSize); err != nil {
+		return nil, err
+	}
+	if block.Sig, err = d.readBytes("Block.Sig", size, 1); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// decodeBlockTx 读取区块中的tx, 区块内的tx不能在边界结束
+func (d *Decoder) decodeBlockTx(field string) (RawTransaction, error) {
+	start := d.off
+	tx, err := d.Decode()
+	if err == io.EOF {
+		return tx, d.fail(field, io.ErrUnexpectedEOF)
+	}
+	var de *DecodeError
+	if errors.As(err, &de) {
+		de.Field = field + "." + de.Field
+	} else if err != nil {
+		err = d.failAt(field, start, err)
+	}
+	return tx, err
+}
+
+// Encode 序列化区块并hex编码
+func (block *Block) Encode() (string, error) {
+	b, err := block.EncodeBytes()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// EncodeBytes 序列化区块(包含签名)
+func (block *Block) EncodeBytes() ([]byte, error) {
+	b, err := block.appendHeader(nil)
+	if err != nil {
+		return nil, err
+	}
+	b = AppendCompactSize(b, uint64(len(block.Vtx)))
+	for i := range block.Vtx {
+		if b, err = block.Vtx[i].AppendSerialize(b, block.serializer); err != nil {
+			return nil, fmt.Errorf("serialize vtx[%d] err, %v", i, err)
+		}
+	}
+	b = AppendCompactSize(b, uint64(len(block.Sig)))
+	return append(b, block.Sig...), nil
+}
+
+// appendHeader 序列化 nVersion ... txMint, 即计算区块hash的数据
+func (block *Block) appendHeader(dst []byte) ([]byte, error) {
+	if block.serializer == nil {
+		return nil, errors.New("block serializer not set, use NewBlock or DecodeBlock")
+	}
+	var b [8]byte
+	binary.LittleEndian.PutUint16(b[0:], block.Version)
+	binary.LittleEndian.PutUint16(b[2:], uint16(block.Type))
+	binary.LittleEndian.PutUint32(b[4:], block.Timestamp)
+	dst = append(dst, b[:]...)
+	dst = append(dst, block.HashPrev[:]...)
+	dst = append(dst, block.HashMerkle[:]...)
+	dst = AppendCompactSize(dst, uint64(len(block.Proof)))
+	dst = append(dst, block.Proof...)
+	dst, err := block.TxMint.AppendSerialize(dst, block.serializer)
+	if err != nil {
+		return nil, fmt.Errorf("serialize txMint err, %v", err)
+	}
+	return dst, nil
+}
+
+// Height 区块高度: 创世块为0, 扩展块与前一区块相同, 其他为前一区块高度+1 (区块hash中包含了高度)
+func (block *Block) Height() uint32 {
+	switch block.Type {
+	case BlockTypeGenesis:
+		return 0
+	case BlockTypeExtended:
+		return binary.LittleEndian.Uint32(block.HashPrev[28:])
+	default:
+		return binary.LittleEndian.Uint32(block.HashPrev[28:]) + 1
+	}
+}
+
+// HashBytes 区块hash(内存字节序): blake2b(nVersion ... txMint), 最后4字节替换为区块高度
+func (block *Block) HashBytes() ([32]byte, error) {
+	b, err := block.appendHeader(nil)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	hash := blake2b.Sum256(b)
+	binary.LittleEndian.PutUint32(hash[28:], block.Height())
+	return hash, nil
+}
+
+// Hash 区块hash(hex, 与rpc返回的格式一致, 前8位为高度), 分支origin块的hash即为fork id
+func (block *Block) Hash() (string, error) {
+	hash, err := block.HashBytes()
+	if err != nil {
+		return "", err
+	}
+	return CopyReverseThenEncodeHex(hash[:]), nil
+}
+
+// PrevHash 前一区块hash(hex)
+func (block *Block) PrevHash() string {
+	return CopyReverseThenEncodeHex(block.HashPrev[:])
+}
+
+// SetPrevHash 设置前一区块hash(hex)
+func (block *Block) SetPrevHash(prev string) error {
+	b, err := hex.DecodeString(prev)
+	if err != nil {
+		return fmt.Errorf("invalid prev hash, %v", err)
+	}
+	if len(b) != 32 {
+		return fmt.Errorf("invalid prev hash len %d", len(b))
+	}
+	copy(block.HashPrev[:], reverseBytes(b))
+	return nil
+}
+
+// Txids 区块中的txid, 顺序与 Vtx 一致(不含铸币tx)
+func (block *Block) Txids() ([]string, error) {
+	ret := make([]string, 0, len(block.Vtx))
+	for i := range block.Vtx {
+		txid, err := block.Vtx[i].Txid(block.serializer)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, txid)
+	}
+	return ret, nil
+}
@@ -0,0 +1,101 @@
+package gobbc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testBlock(t *testing.T) *Block {
+	w := TW{T: t}
+	tx, err := BBCSerializer.Deserialize(mustHexDecode(t, testSignedBBCTx))
+	w.Nil(err)
+	mint := RawTransaction{
+		Version:      1,
+		Typ:          uint16(TxTypeStake),
+		Timestamp:    1590474715,
+		Prefix:       PrefixPubk,
+		Amount:       15000000,
+		AddressBytes: tx.AddressBytes,
+		Input:        []byte{},
+		VchData:      []byte{},
+		SignBytes:    []byte{},
+	}
+	mint.HashAnchorBytes = tx.HashAnchorBytes
+	block := NewBlock(BBCSerializer)
+	block.Version = 1
+	block.Type = BlockTypePrimary
+	block.Timestamp = 1590474715
+	w.Nil(block.SetPrevHash("00000009f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2"))
+	block.HashMerkle = [32]byte{1, 2, 3}
+	block.Proof = []byte{0xaa, 0xbb}
+	block.TxMint = mint
+	block.Vtx = []RawTransaction{tx, tx}
+	block.Sig = make([]byte, 64)
+	return block
+}
+
+func TestBlockEncodeDecode(t *testing.T) {
+	w := TW{T: t}
+	block := testBlock(t)
+	hexed, err := block.Encode()
+	w.Nil(err)
+
+	for _, s := range []Serializer{BBCSerializer, BBCStrictSerializer, BBCMainnet} {
+		decoded, err := DecodeBlock(s, hexed)
+		w.Nil(err)
+		w.Equal(block.Type, decoded.Type).Equal(block.HashPrev, decoded.HashPrev).Equal(block.Proof, decoded.Proof)
+		w.Equal(block.TxMint, decoded.TxMint).Equal(block.Vtx, decoded.Vtx).Equal(block.Sig, decoded.Sig)
+		reEncoded, err := decoded.Encode()
+		w.Nil(err).Equal(hexed, reEncoded)
+	}
+
+	w.Equal(uint32(10), block.Height())
+	w.Equal("00000009f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2", block.PrevHash())
+	hash, err := block.Hash()
+	w.Nil(err).True(strings.HasPrefix(hash, "0000000a"), hash)
+
+	// 签名与vtx不影响区块hash
+	block.Sig = nil
+	block.Vtx = nil
+	hash2, err := block.Hash()
+	w.Nil(err).Equal(hash, hash2)
+	block.Proof = nil
+	hash2, err = block.Hash()
+	w.Nil(err).True(hash != hash2)
+
+	block.Type = BlockTypeExtended
+	w.Equal(uint32(9), block.Height())
+	block.Type = BlockTypeGenesis
+	w.Equal(uint32(0), block.Height())
+
+	txids, err := testBlock(t).Txids()
+	w.Nil(err).Equal(2, len(txids))
+	txid, err := testBlock(t).Vtx[0].Txid(BBCSerializer)
+	w.Nil(err).Equal(txid, txids[0])
+
+	_, err = (&Block{}).Encode()
+	w.True(err != nil, "serializer required")
+}
+
+func TestDecodeBlockError(t *testing.T) {
+	w := TW{T: t}
+	hexed, err := testBlock(t).Encode()
+	w.Nil(err)
+
+	_, err = DecodeBlock(BBCSerializer, hexed+"00")
+	w.Nil(err)
+	_, err = DecodeBlock(BBCStrictSerializer, hexed+"00")
+	w.True(errors.Is(err, ErrTrailingData), err)
+
+	_, err = DecodeBlock(BBCSerializer, hexed[:len(hexed)-2])
+	w.True(err != nil)
+	_, err = DecodeBlock(BBCSerializer, "")
+	w.True(err != nil)
+
+	var de *DecodeError
+	// 截断在第二个vtx中
+	_, err = DecodeBlock(BBCStrictSerializer, hexed[:len(hexed)-2*(1+64+40)])
+	w.True(errors.As(err, &de), err).True(strings.HasPrefix(de.Field, "Block.Vtx[1]."), de.Field)
+	w.True(errors.Is(err, ErrTruncated))
+}
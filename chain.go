@@ -0,0 +1,182 @@
+package gobbc
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// FeePolicy 手续费策略，参考core CalcMinTxFee:
+// 不带data时为MinTxFee, 带data时每200字节(不足200按200计)增加 2*MinTxFee, 超过5个200字节后每200字节增加 4*MinTxFee
+type FeePolicy struct {
+	MinTxFee int64 //最低手续费(最小单位)
+}
+
+// MinFee vchData长度为dataLen时的最低手续费
+func (p FeePolicy) MinFee(dataLen int) int64 {
+	if dataLen <= 0 {
+		return p.MinTxFee
+	}
+	multiplier := int64((dataLen + 199) / 200)
+	if multiplier > 5 {
+		return p.MinTxFee + p.MinTxFee*10 + (multiplier-5)*p.MinTxFee*4
+	}
+	return p.MinTxFee + multiplier*p.MinTxFee*2
+}
+
+// AddressRules 链上允许作为转账目标的地址
+type AddressRules struct {
+	AllowPubkey   bool
+	TemplateTypes []TemplateType //允许的模版类型，为空时不限制
+}
+
+// ValidateDestination 检查地址是否符合规则
+func (r AddressRules) ValidateDestination(prefix uint8, data [32]byte) error {
+	switch prefix {
+	case PrefixPubk:
+		if !r.AllowPubkey {
+			return errors.New("pubkey address not allowed")
+		}
+		return nil
+	case PrefixTemplate:
+		if len(r.TemplateTypes) == 0 {
+			return nil
+		}
+		typ := TemplateType(uint16(data[0]) | uint16(data[1])<<8)
+		for _, t := range r.TemplateTypes {
+			if t == typ {
+				return nil
+			}
+		}
+		return fmt.Errorf("template type %s(%d) not allowed", typ, typ)
+	default:
+		return fmt.Errorf("unknown address prefix %d", prefix)
+	}
+}
+
+// ChainParams 链参数，不同的链(BBC主网、测试网、MKF等)在序列化、anchor、tx版本、手续费等方面有差异,
+// ChainParams 内嵌了 Serializer, 可以直接传给 DecodeRawTransaction 等需要 Serializer 的函数
+type ChainParams struct {
+	Serializer
+	Name             string
+	Symbol           string //币种符号, 如 BBC
+	GenesisAnchor    string //主链fork id(创世块hash), 为空表示不使用anchor(如MKF)或未知
+	DefaultTxVersion uint16
+	Fee              FeePolicy
+	Precision        int64 //1 coin = Precision 最小单位
+	Address          AddressRules
+	Forks            *ForkRegistry //该链已知的分支，为空时使用 DefaultForkRegistry
+}
+
+// MinTxFee vchData长度为dataLen时的最低手续费(最小单位)
+func (p *ChainParams) MinTxFee(dataLen int) int64 {
+	return p.Fee.MinFee(dataLen)
+}
+
+// EstimateFee rtx当前数据对应的最低手续费(最小单位)
+func (p *ChainParams) EstimateFee(rtx *RawTransaction) int64 {
+	return p.MinTxFee(len(rtx.VchData))
+}
+
+// ToCoin 最小单位 -> 币
+func (p *ChainParams) ToCoin(amount int64) decimal.Decimal {
+	return decimal.New(amount, 0).Div(decimal.NewFromInt(p.precision()))
+}
+
+// FromCoin 币 -> 最小单位
+func (p *ChainParams) FromCoin(amount decimal.Decimal) int64 {
+	return amount.Mul(decimal.NewFromInt(p.precision())).IntPart()
+}
+
+func (p *ChainParams) precision() int64 {
+	if p == nil || p.Precision == 0 {
+		return Precision
+	}
+	return p.Precision
+}
+
+// 已知的链参数
+var (
+	BBCMainnet = &ChainParams{
+		Serializer:       BBCSerializer,
+		Name:             "bbc",
+		Symbol:           "BBC",
+		GenesisAnchor:    "00000000b0a9be545f022309e148894d1e1c853ccac3ef04cb6f5e5c70f41a70",
+		DefaultTxVersion: 1,
+		Fee:              FeePolicy{MinTxFee: 10000},
+		Precision:        Precision,
+		Address:          AddressRules{AllowPubkey: true},
+	}
+	// BBCTestnet 测试网创世块与core版本/启动参数有关，需要时通过 RegisterFork 注册
+	BBCTestnet = &ChainParams{
+		Serializer:       BBCSerializer,
+		Name:             "bbc-testnet",
+		Symbol:           "BBC",
+		DefaultTxVersion: 1,
+		Fee:              FeePolicy{MinTxFee: 10000},
+		Precision:        Precision,
+		Address:          AddressRules{AllowPubkey: true},
+	}
+	MKFMainnet = &ChainParams{
+		Serializer:       MKFSerializer,
+		Name:             "mkf",
+		Symbol:           "MKF",
+		DefaultTxVersion: 2,
+		Fee:              FeePolicy{MinTxFee: 10000},
+		Precision:        Precision,
+		Address:          AddressRules{AllowPubkey: true},
+	}
+)
+
+var chainParamsRegistry = struct {
+	sync.RWMutex
+	m map[string]*ChainParams
+}{m: map[string]*ChainParams{}}
+
+func init() {
+	for _, p := range []*ChainParams{BBCMainnet, BBCTestnet, MKFMainnet} {
+		if err := RegisterChainParams(p); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterChainParams 注册链参数，名称不能重复
+func RegisterChainParams(p *ChainParams) error {
+	if p == nil || p.Name == "" || p.Serializer == nil {
+		return errors.New("chain params name and serializer required")
+	}
+	chainParamsRegistry.Lock()
+	defer chainParamsRegistry.Unlock()
+	if _, ok := chainParamsRegistry.m[p.Name]; ok {
+		return fmt.Errorf("chain params %s already registered", p.Name)
+	}
+	chainParamsRegistry.m[p.Name] = p
+	return nil
+}
+
+// GetChainParams 根据名称获取已注册的链参数
+func GetChainParams(name string) (*ChainParams, error) {
+	chainParamsRegistry.RLock()
+	defer chainParamsRegistry.RUnlock()
+	p, ok := chainParamsRegistry.m[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown chain: %s", name)
+	}
+	return p, nil
+}
+
+// ChainParamsList 已注册的链参数，按名称排序
+func ChainParamsList() []*ChainParams {
+	chainParamsRegistry.RLock()
+	defer chainParamsRegistry.RUnlock()
+	var ret []*ChainParams
+	for _, p := range chainParamsRegistry.m {
+		ret = append(ret, p)
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
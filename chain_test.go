@@ -0,0 +1,96 @@
+package gobbc
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestFeePolicy(t *testing.T) {
+	w := TW{T: t}
+	p := FeePolicy{MinTxFee: 10000}
+	for _, tt := range []struct {
+		dataLen int
+		fee     int64
+	}{
+		{0, 10000},
+		{1, 30000}, //0.03
+		{200, 30000},
+		{201, 50000},
+		{1000, 110000},
+		{1001, 150000},
+	} {
+		w.Equal(tt.fee, p.MinFee(tt.dataLen), tt.dataLen)
+	}
+}
+
+func TestChainParamsRegistry(t *testing.T) {
+	w := TW{T: t}
+	for _, name := range []string{"bbc", "bbc-testnet", "mkf"} {
+		p, err := GetChainParams(name)
+		w.Nil(err).Equal(name, p.Name)
+	}
+	_, err := GetChainParams("xxx")
+	w.True(err != nil)
+	w.True(RegisterChainParams(&ChainParams{Name: "bbc", Serializer: BBCSerializer}) != nil, "duplicated")
+	w.True(RegisterChainParams(&ChainParams{Name: "no-serializer"}) != nil)
+	w.Equal(3 <= len(ChainParamsList()), true)
+
+	w.Equal("1.23", MKFMainnet.ToCoin(1230000).String())
+	w.Equal(int64(1230000), BBCMainnet.FromCoin(decimal.RequireFromString("1.23")))
+}
+
+func TestTXBuilderWithChainParams(t *testing.T) {
+	w := TW{T: t}
+	createTX := "01000000dbb7cc5e00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b0000000000182e7a2ae807032941897bd7e01a3221b91cdb63f0a2d64dcad937c9f98e3c55e01017c755b96a15a57a7253d2bf80a1d9c4ca84a9a70da6ab77ab96661fc7b7193cfb0c412000000000010270000000000000000"
+	builder := func() *TXBuilder {
+		return NewTXBuilder().
+			SetChainParams(BBCMainnet).
+			SetAnchor(BBCMainnet.GenesisAnchor).
+			SetTimestamp(1590474715).
+			AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1).
+			SetAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm").
+			SetAmount(1.23)
+	}
+	tx, err := builder().SetMinFee().Build()
+	w.Nil(err)
+	encodeTX, err := tx.Encode(BBCMainnet, false)
+	w.Nil(err).Equal(createTX, encodeTX)
+
+	_, err = builder().SetFee(0.001).Build()
+	w.True(err != nil, "fee too low")
+	_, err = builder().SetData("", []byte("hello")).SetFee(0.01).Build()
+	w.True(err != nil, "fee too low with data")
+
+	restricted := *BBCMainnet
+	restricted.Address = AddressRules{TemplateTypes: []TemplateType{TemplateTypeMultisig}}
+	_, err = builder().SetChainParams(&restricted).SetFee(0.01).Build()
+	w.True(err != nil, "pubkey address not allowed")
+
+	//链参数的版本和精度在 Build 时使用, 与调用顺序无关
+	coarse := *BBCMainnet
+	coarse.Precision = 1000
+	coarse.Fee.MinTxFee = 10
+	coarse.DefaultTxVersion = 2
+	for _, b := range []*TXBuilder{
+		builder().SetFee(0.01).SetVersion(1).SetChainParams(&coarse),
+		builder().SetChainParams(&coarse).SetVersion(1).SetFee(0.01),
+	} {
+		tx, err := b.Build()
+		w.Nil(err).Equal(uint16(1), tx.Version).Equal(int64(1230), tx.Amount).Equal(int64(10), tx.TxFee)
+	}
+	tx, err = builder().SetChainParams(&coarse).SetChainParams(BBCMainnet).SetMinFee().Build()
+	w.Nil(err).Equal(BBCMainnet.DefaultTxVersion, tx.Version).Equal(int64(1230000), tx.Amount)
+	_, err = NewTXBuilder().SetMinFee().AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1).SetAmount(1).Build()
+	w.True(err != nil, "SetMinFee without chain params")
+
+	//ChainParams 值也可以作为 Serializer 用于流式编解码
+	_, err = NewStrictSerializer(*BBCMainnet, DefaultDecodeLimits)
+	w.Nil(err)
+
+	mkf := "02000000a61a4e5f0000000001e6c1600226855e8aac1e3d60f76e7b326527e4a72620f0f77af2ae86901a4e5f0002030001e21d6d49931304681ac8ed683d8e90dc8eb6793a875d5361b0bb72bdf9601823000000000030750000000000000000"
+	decoded, err := DecodeRawTransaction(MKFMainnet, mkf, true)
+	w.Nil(err).Equal(MKFMainnet.DefaultTxVersion, decoded.Version)
+	txid, err := decoded.Txid(MKFMainnet)
+	w.Nil(err).Equal("5f4e1aa697bcccd1a215c94a58c3acc5cd60330bd27f70cf04147605db680195", txid)
+}
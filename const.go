@@ -1,5 +1,6 @@
 package gobbc
 
+// Precision 默认精度(BBC/MKF), 不同链的精度参考 ChainParams.Precision
 const Precision = 1000000
 
 // TemplateType 模版类型
@@ -46,6 +47,77 @@ func (typ TemplateType) String() string {
 		return "vote"
 	case TemplateTypePayment:
 		return "payment"
+	case templateDexorder:
+		return "dexorder"
+	default:
+		return "unknown"
+	}
+}
+
+// TxType tx类型, 参考core CTransaction
+type TxType uint16
+
+// tx类型
+const (
+	TxTypeToken   TxType = 0x0000 //普通转账
+	TxTypeCert    TxType = 0xff00 //dpos delegate 注册(certification)
+	TxTypeGenesis TxType = 0x0100 //创世块/分支origin块的铸币
+	TxTypeStake   TxType = 0x0200 //dpos出块奖励
+	TxTypeWork    TxType = 0x0300 //pow出块奖励
+)
+
+func (typ TxType) String() string {
+	switch typ {
+	case TxTypeToken:
+		return "token"
+	case TxTypeCert:
+		return "certification"
+	case TxTypeGenesis:
+		return "genesis"
+	case TxTypeStake:
+		return "stake"
+	case TxTypeWork:
+		return "work"
+	default:
+		return "unknown"
+	}
+}
+
+// IsValid 是否为已知的tx类型
+func (typ TxType) IsValid() bool { return typ.String() != "unknown" }
+
+// IsMint 是否为铸币tx(genesis, stake, work), 铸币tx没有输入和手续费
+func (typ TxType) IsMint() bool {
+	return typ == TxTypeGenesis || typ == TxTypeStake || typ == TxTypeWork
+}
+
+// BlockType 区块类型, 参考core CBlock
+type BlockType uint16
+
+// 区块类型
+const (
+	BlockTypeGenesis    BlockType = 0xffff //主链创世块
+	BlockTypeOrigin     BlockType = 0xff00 //分支origin块
+	BlockTypePrimary    BlockType = 0x0001 //主链块
+	BlockTypeSubsidiary BlockType = 0x0002 //分支块
+	BlockTypeExtended   BlockType = 0x0004 //分支扩展块
+	BlockTypeVacant     BlockType = 0x0008 //分支空块
+)
+
+func (typ BlockType) String() string {
+	switch typ {
+	case BlockTypeGenesis:
+		return "genesis"
+	case BlockTypeOrigin:
+		return "origin"
+	case BlockTypePrimary:
+		return "primary"
+	case BlockTypeSubsidiary:
+		return "subsidiary"
+	case BlockTypeExtended:
+		return "extended"
+	case BlockTypeVacant:
+		return "vacant"
 	default:
 		return "unknown"
 	}
@@ -0,0 +1,207 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// coreAmountDecimals core rpc 中金额固定输出6位小数
+const coreAmountDecimals = 6
+
+// coreDataMsgPrefix core TxToJSON 中以此开头的vchData按文本输出, 其他按hex输出
+const coreDataMsgPrefix = "msg:"
+
+// CoreTransaction 与core rpc decodetransaction(gettransaction 的 transaction, core TxToJSON)输出格式相同的交易,
+// data 为vchData的hex, 以 msg: 开头时为文本
+type CoreTransaction struct {
+	Txid          string      `json:"txid"`
+	Version       uint16      `json:"version"`
+	Type          string      `json:"type"`
+	Time          uint32      `json:"time"`
+	LockUntil     uint32      `json:"lockuntil"`
+	Anchor        string      `json:"anchor"`
+	BlockHash     string      `json:"blockhash,omitempty"` //所在区块, 离线无法获取
+	Vin           []CoreVin   `json:"vin"`
+	SendFrom      string      `json:"sendfrom"`
+	SendTo        string      `json:"sendto"`
+	Amount        json.Number `json:"amount"`
+	TxFee         json.Number `json:"txfee"`
+	Data          string      `json:"data"`
+	Sig           string      `json:"sig"`
+	Fork          string      `json:"fork"`
+	Confirmations *int        `json:"confirmations,omitempty"` //gettransaction 时输出
+}
+
+// CoreVin .
+type CoreVin struct {
+	Txid string `json:"txid"`
+	Vout int    `json:"vout"`
+}
+
+// ToCore 转换为 core decodetransaction 格式, serializer 用于计算txid,
+// sendfrom 只能从签名数据确定(模版地址转出), 公钥地址转出时为空, 参考 ToCoreWithSender
+func (rtx *RawTransaction) ToCore(serializer Serializer) (*CoreTransaction, error) {
+	return rtx.ToCoreWithSender(serializer, nil)
+}
+
+// ToCoreWithSender 与 ToCore 相同, 使用 resolver 确定 sendfrom(参考 SenderAddress), 无法确定时为空
+func (rtx *RawTransaction) ToCoreWithSender(serializer Serializer, resolver *SenderResolver) (*CoreTransaction, error) {
+	txid, err := rtx.Txid(serializer)
+	if err != nil {
+		return nil, err
+	}
+	tx := rtx.ToTransaction(true)
+	if resolver == nil {
+		resolver = &SenderResolver{Serializer: serializer}
+	}
+	if len(rtx.SignBytes) > 0 {
+		tx.From, _ = rtx.SenderAddress(resolver)
+	}
+	ctx := CoreTransaction{
+		Txid:      txid,
+		Version:   tx.Version,
+		Type:      tx.TypeName,
+		Time:      tx.Timestamp,
+		LockUntil: tx.LockUntil,
+		Anchor:    tx.HashAnchor,
+		Vin:       make([]CoreVin, 0, len(tx.Vin)),
+		SendFrom:  tx.From,
+		SendTo:    tx.Address,
+		Amount:    coreAmount(tx.Amount),
+		TxFee:     coreAmount(tx.TxFee),
+		Data:      coreData(rtx.VchData),
+		Sig:       tx.Sign,
+		Fork:      tx.HashAnchor, //anchor 即交易所在分支的id
+	}
+	for _, vin := range tx.Vin {
+		ctx.Vin = append(ctx.Vin, CoreVin{Txid: vin.Txid, Vout: vin.Vout})
+	}
+	return &ctx, nil
+}
+
+// ToCoreJSON core decodetransaction 格式的json
+func (rtx *RawTransaction) ToCoreJSON(serializer Serializer) ([]byte, error) {
+	ctx, err := rtx.ToCore(serializer)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ctx)
+}
+
+// FromCoreJSON 解析 core decodetransaction 输出的json, txid 不为空时校验与交易数据一致
+func FromCoreJSON(serializer Serializer, b []byte) (*RawTransaction, error) {
+	var ctx CoreTransaction
+	if err := json.Unmarshal(b, &ctx); err != nil {
+		return nil, err
+	}
+	return ctx.RawTransaction(serializer)
+}
+
+// RawTransaction 还原交易, txid 不为空时校验与交易数据一致, sendfrom, fork, blockhash 等交易数据以外的字段忽略
+func (ctx *CoreTransaction) RawTransaction(serializer Serializer) (*RawTransaction, error) {
+	typ, err := parseTxType(ctx.Type)
+	if err != nil {
+		return nil, err
+	}
+	amount, err := parseCoreAmount("amount", ctx.Amount)
+	if err != nil {
+		return nil, err
+	}
+	fee, err := parseCoreAmount("txfee", ctx.TxFee)
+	if err != nil {
+		return nil, err
+	}
+	tx := Transaction{
+		RawTransaction: RawTransaction{
+			Version:   ctx.Version,
+			Typ:       uint16(typ),
+			Timestamp: ctx.Time,
+			LockUntil: ctx.LockUntil,
+			SizeIn:    uint64(len(ctx.Vin)),
+			Amount:    amount,
+			TxFee:     fee,
+		},
+		HashAnchor: ctx.Anchor,
+		Address:    ctx.SendTo,
+		Sign:       ctx.Sig,
+	}
+	if dest, err := NewCDestinationFromAddress(ctx.SendTo); err == nil {
+		tx.Prefix = dest.Prefix
+	}
+	data, err := parseCoreData(ctx.Data)
+	if err != nil {
+		return nil, err
+	}
+	tx.Data = hex.EncodeToString(data)
+	sig, err := hex.DecodeString(ctx.Sig)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid sig")
+	}
+	tx.SizeOut, tx.SizeSign = uint64(len(data)), uint64(len(sig))
+	for _, vin := range ctx.Vin {
+		tx.Vin = append(tx.Vin, Vin{Txid: vin.Txid, Vout: vin.Vout})
+	}
+
+	rtx, err := tx.rawTransaction()
+	if err != nil {
+		return nil, err
+	}
+	if ctx.Txid != "" {
+		txid, err := rtx.Txid(serializer)
+		if err != nil {
+			return nil, err
+		}
+		if txid != ctx.Txid {
+			return nil, fmt.Errorf("txid mismatch, expected %s, calculated %s", ctx.Txid, txid)
+		}
+	}
+	return &rtx, nil
+}
+
+func coreData(b []byte) string {
+	if strings.HasPrefix(string(b), coreDataMsgPrefix) {
+		return string(b)
+	}
+	return hex.EncodeToString(b)
+}
+
+func parseCoreData(s string) ([]byte, error) {
+	if strings.HasPrefix(s, coreDataMsgPrefix) {
+		return []byte(s), nil
+	}
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid data")
+	}
+	return b, nil
+}
+
+func coreAmount(v int64) json.Number {
+	return json.Number(decimal.New(v, -coreAmountDecimals).StringFixed(coreAmountDecimals))
+}
+
+func parseCoreAmount(field string, n json.Number) (int64, error) {
+	d, err := decimal.NewFromString(n.String())
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s", field)
+	}
+	v := d.Shift(coreAmountDecimals)
+	if !v.Equal(v.Truncate(0)) {
+		return 0, fmt.Errorf("invalid %s %s, more than %d decimals", field, n, coreAmountDecimals)
+	}
+	return v.IntPart(), nil
+}
+
+func parseTxType(name string) (TxType, error) {
+	for _, typ := range []TxType{TxTypeToken, TxTypeCert, TxTypeGenesis, TxTypeStake, TxTypeWork} {
+		if typ.String() == name {
+			return typ, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown tx type %q", name)
+}
@@ -0,0 +1,98 @@
+package gobbc
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestCoreJSON(t *testing.T) {
+	w := TW{T: t}
+	const data = "010000004aeaed5d00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b00000000002799a49bcd8ca8723aa00aad86cec19d4d095191c20ce44000cfa7f6b09e9ed5d002b8336b3f242db6ecdc939c168f9613b14f0f4fd00418c3c9ba849c14aeaed5d0101f30b1fd894ba3eacf1b2309ce9fcb606892a70604af5791a732df423e47f001d9c64cd1d000000006400000000000000008164f1a77bd0e00f8023ffa2f7e0a76eb795414d9a57eb2f4ce5e9cc730c8103c501e1cbd24fa95312b81d2dc5ef6f60c39a9485819d4fa11bcfdde5f99151c8a4f981e14068ae196ce63ef403bc335ff439a00d1f00cc3e45cfc057354ea408cafad8e1fb769de3672a155545d490813e1c6eeefb7b4dec678e669c5de7e3c20b07"
+	// 由本包生成, 字段顺序参考core TxToJSON; 与节点实际输出的比较见 TestCoreJSONCoreVector
+	const expected = `{"txid":"5dedea4ac48d33229b61a4b7d7f5e8e84833775250cb8fa7091564943b7f8e1f","version":1,"type":"token","time":1575873098,"lockuntil":0,` +
+		`"anchor":"00000000b0a9be545f022309e148894d1e1c853ccac3ef04cb6f5e5c70f41a70",` +
+		`"vin":[{"txid":"5dede9096b7ffa0c0044ce201c1995d0d419ec6cd8aa00aa2387cad8bc499a79","vout":0},{"txid":"5dedea4ac149a89b3c8c4100fdf4f0143b61f968c139c9cd6edb42f2b336832b","vout":1}],` +
+		`"sendfrom":"20m07atym1beahmdk267hkqrgvhw1x0gj3bwth8q7yxcyfgcbszbgc19f","sendto":"1yc5hzp4mq8zaswdj62eekz5p0t4jmw309btqj6kk5qt27s3z00embbrg","amount":499.999900,"txfee":0.000100,"data":"",` +
+		`"sig":"64f1a77bd0e00f8023ffa2f7e0a76eb795414d9a57eb2f4ce5e9cc730c8103c501e1cbd24fa95312b81d2dc5ef6f60c39a9485819d4fa11bcfdde5f99151c8a4f981e14068ae196ce63ef403bc335ff439a00d1f00cc3e45cfc057354ea408cafad8e1fb769de3672a155545d490813e1c6eeefb7b4dec678e669c5de7e3c20b07",` +
+		`"fork":"00000000b0a9be545f022309e148894d1e1c853ccac3ef04cb6f5e5c70f41a70"}`
+
+	tx, err := DecodeRawTransaction(BBCSerializer, data, true)
+	w.Nil(err)
+	// 签名数据中的模版数据可以是delegate或proof, 没有 hints 时 sendfrom 为空
+	b, err := tx.RawTransaction.ToCoreJSON(BBCSerializer)
+	w.Nil(err).Equal(strings.Replace(expected, "20m07atym1beahmdk267hkqrgvhw1x0gj3bwth8q7yxcyfgcbszbgc19f", "", 1), string(b))
+	ctx, err := tx.RawTransaction.ToCoreWithSender(BBCSerializer, &SenderResolver{Candidates: []string{"20m07atym1beahmdk267hkqrgvhw1x0gj3bwth8q7yxcyfgcbszbgc19f"}})
+	w.Nil(err)
+	b, err = json.Marshal(ctx)
+	w.Nil(err).Equal(expected, string(b))
+
+	rtx, err := FromCoreJSON(BBCSerializer, b)
+	w.Nil(err)
+	enc, err := rtx.Encode(BBCSerializer, true)
+	w.Nil(err).Equal(data, enc)
+
+	// core 输出的格式可能带空白, 金额不足6位小数
+	rtx, err = FromCoreJSON(BBCSerializer, []byte(strings.Replace(strings.Replace(expected, ",", ", ", -1), "0.000100", "0.0001", 1)))
+	w.Nil(err).Equal(int64(100), rtx.TxFee)
+
+	// 以 msg: 开头的data按文本输出
+	msgTx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+		SetAddress("1yc5hzp4mq8zaswdj62eekz5p0t4jmw309btqj6kk5qt27s3z00embbrg").
+		SetAmount(1).SetFee(0.01).
+		SetRawData([]byte("msg:hello")).
+		Build()
+	w.Nil(err)
+	ctx, err = msgTx.ToCore(BBCSerializer)
+	w.Nil(err).Equal("msg:hello", ctx.Data)
+	b, err = json.Marshal(ctx)
+	w.Nil(err)
+	rtx, err = FromCoreJSON(BBCSerializer, b)
+	w.Nil(err).Equal(msgTx.VchData, rtx.VchData)
+	ctx, err = rtx.ToCore(BBCSerializer)
+	w.Nil(err).Equal("msg:hello", ctx.Data)
+
+	for _, tt := range []struct{ name, old, new string }{
+		{"txid", `"txid":"5dedea4ac48d`, `"txid":"5dedea4ac48e`},
+		{"type", `"type":"token"`, `"type":"transfer"`},
+		{"amount decimals", `499.999900`, `499.9999001`},
+		{"data", `"data":""`, `"data":"0"`},
+	} {
+		s := strings.Replace(expected, tt.old, tt.new, 1)
+		w.True(s != expected, tt.name)
+		_, err = FromCoreJSON(BBCSerializer, []byte(s))
+		w.True(err != nil, tt.name)
+	}
+}
+
+// TestCoreJSONCoreVector 与core rpc decodetransaction 的输出比较
+func TestCoreJSONCoreVector(t *testing.T) {
+	var v struct {
+		Hex               string          `json:"hex"`
+		DecodeTransaction json.RawMessage `json:"decodetransaction"`
+	}
+	loadCoreVector(t, "decodetransaction", &v)
+	w := TW{T: t}
+	rtx, err := FromCoreJSON(BBCSerializer, v.DecodeTransaction)
+	w.Nil(err)
+	enc, err := rtx.Encode(BBCSerializer, true)
+	w.Nil(err).Equal(v.Hex, enc)
+
+	var core CoreTransaction
+	w.Nil(json.Unmarshal(v.DecodeTransaction, &core))
+	ctx, err := rtx.ToCoreWithSender(BBCSerializer, &SenderResolver{Serializer: BBCSerializer, Candidates: []string{core.SendFrom}})
+	w.Nil(err)
+	ctx.BlockHash, ctx.Confirmations = core.BlockHash, core.Confirmations
+	w.Equal(core, *ctx)
+
+	// 字段名及顺序与core一致
+	b, err := json.Marshal(ctx)
+	w.Nil(err)
+	var compact bytes.Buffer
+	w.Nil(json.Compact(&compact, v.DecodeTransaction))
+	w.Equal(compact.String(), string(b))
+}
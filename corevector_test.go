@@ -0,0 +1,25 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// loadCoreVector 读取 testdata/core/<name>.json 中从core节点获取的测试数据,
+// 文件不存在时跳过测试(获取方法参考 testdata/core/README.md)
+func loadCoreVector(t *testing.T, name string, v interface{}) {
+	t.Helper()
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "core", name+".json"))
+	if os.IsNotExist(err) {
+		t.Skipf("core vector %s not captured, see testdata/core/README.md", name)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(b, v); err != nil {
+		t.Fatalf("invalid core vector %s: %v", name, err)
+	}
+}
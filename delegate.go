@@ -0,0 +1,69 @@
+package gobbc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// DelegateEnrollData dpos delegate 注册数据(certification tx 的 vchData),
+// 即core MPVSS sealed box 序列化后的数据: vEncryptedCoeff, nR, nS
+// |---8---|---32*n---|---32---|---32---|
+// |   n   |  coeff   |   R    |   S    |
+type DelegateEnrollData struct {
+	EncryptedCoeff [][32]byte
+	R, S           [32]byte
+}
+
+// Bytes .
+func (d DelegateEnrollData) Bytes() []byte {
+	b := make([]byte, 8, 8+32*len(d.EncryptedCoeff)+64)
+	binary.LittleEndian.PutUint64(b, uint64(len(d.EncryptedCoeff)))
+	for _, c := range d.EncryptedCoeff {
+		b = append(b, c[:]...)
+	}
+	b = append(b, d.R[:]...)
+	return append(b, d.S[:]...)
+}
+
+// ParseDelegateEnrollData 解析certification tx 的 vchData
+func ParseDelegateEnrollData(b []byte) (*DelegateEnrollData, error) {
+	if len(b) < 8+64 {
+		return nil, fmt.Errorf("enroll data too short: %d", len(b))
+	}
+	n := binary.LittleEndian.Uint64(b)
+	if n != uint64(len(b)-8-64)/32 || (len(b)-8-64)%32 != 0 {
+		return nil, fmt.Errorf("invalid enroll data len %d for %d coeff", len(b), n)
+	}
+	d := DelegateEnrollData{EncryptedCoeff: make([][32]byte, n)}
+	b = b[8:]
+	for i := range d.EncryptedCoeff {
+		copy(d.EncryptedCoeff[i][:], b[:32])
+		b = b[32:]
+	}
+	copy(d.R[:], b[:32])
+	copy(d.S[:], b[32:])
+	return &d, nil
+}
+
+// BuildDelegateCertTx delegate注册(certification)交易，从delegate模版地址转到delegate模版地址自身，
+// delegateTpl: delegate 模版数据hex, amount 可以为0, enrollData: 注册数据(参考 DelegateEnrollData),
+// 调用方需要继续设置输入(delegate模版地址的utxo)、时间戳、手续费后 Build,
+// 签名使用delegate私钥: rtx.SignWithPrivateKey(serializer, delegateTpl, delegatePrivk)
+func BuildDelegateCertTx(delegateTpl string, amount float64, enrollData []byte, anchor string) *TXBuilder {
+	b := NewTXBuilder().SetTxType(TxTypeCert).SetAnchor(anchor)
+	if _, err := ParseDelegateTemplateHex(delegateTpl); err != nil {
+		b.SetErr(err)
+		return b
+	}
+	if len(enrollData) == 0 {
+		b.SetErr(errors.New("enroll data required"))
+		return b
+	}
+	addr, err := TemplateAddress(delegateTpl)
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	return b.SetAddress(addr).SetAmount(amount).SetRawData(enrollData)
+}
@@ -0,0 +1,102 @@
+package gobbc
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestDelegateTemplate(t *testing.T) {
+	w := TW{T: t}
+	delegate, err := MakeKeyPair()
+	w.Nil(err)
+	owner, err := MakeKeyPair()
+	w.Nil(err)
+	ownerDest, err := NewCDestinationFromAddress(owner.Addr)
+	w.Nil(err)
+
+	addr, tplHex, err := CreateTemplateDataDelegate(delegate.Pubk, ownerDest)
+	w.Nil(err)
+	w.True(strings.HasPrefix(addr, "20m0"), addr) //模版类型5
+	w.Equal(TemplateTypeDelegate, GetTemplateType(tplHex))
+
+	tplAddr, err := TemplateAddress(tplHex)
+	w.Nil(err).Equal(addr, tplAddr)
+	dest, err := NewCDestinationFromAddress(addr)
+	w.Nil(err).Equal(TemplateTypeDelegate, dest.TemplateType())
+
+	tpl, err := ParseDelegateTemplateHex(tplHex)
+	w.Nil(err).Equal(delegate.Pubk, CopyReverseThenEncodeHex(tpl.Delegate)).Equal(owner.Addr, tpl.Owner.String())
+
+	_, err = ParseDelegateTemplateHex(tplHex[:len(tplHex)-2])
+	w.True(err != nil, "invalid len")
+	_, err = ParseDelegateTemplateHex("0200" + tplHex[4:])
+	w.True(err != nil, "not delegate")
+}
+
+func TestDelegateEnrollData(t *testing.T) {
+	w := TW{T: t}
+	d := DelegateEnrollData{EncryptedCoeff: [][32]byte{{1}, {2}, {3}}, R: [32]byte{4}, S: [32]byte{5}}
+	b := d.Bytes()
+	w.Equal(8+32*3+64, len(b))
+	parsed, err := ParseDelegateEnrollData(b)
+	w.Nil(err).Equal(d, *parsed)
+
+	_, err = ParseDelegateEnrollData(b[:len(b)-1])
+	w.True(err != nil, "truncated")
+	_, err = ParseDelegateEnrollData(append(b, 0))
+	w.True(err != nil, "trailing")
+}
+
+func TestBuildDelegateCertTx(t *testing.T) {
+	w := TW{T: t}
+	delegate, err := MakeKeyPair()
+	w.Nil(err)
+	ownerDest, err := NewCDestinationFromAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm")
+	w.Nil(err)
+	addr, tplHex, err := CreateTemplateDataDelegate(delegate.Pubk, ownerDest)
+	w.Nil(err)
+
+	enroll := DelegateEnrollData{EncryptedCoeff: [][32]byte{{1}, {2}}, R: [32]byte{3}, S: [32]byte{4}}
+	rtx, err := BuildDelegateCertTx(tplHex, 0, enroll.Bytes(), BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1).
+		SetFee(0.03).
+		Build()
+	w.Nil(err).Equal(TxTypeCert, rtx.TxType())
+
+	w.Nil(rtx.SignWithPrivateKey(BBCMainnet, tplHex, delegate.Privk))
+	hexed, err := rtx.Encode(BBCMainnet, true)
+	w.Nil(err)
+	tx, err := DecodeRawTransaction(BBCMainnet, hexed, true)
+	w.Nil(err).Equal(addr, tx.Address).Equal(TxTypeCert.String(), tx.TypeName)
+
+	decoded, err := BBCMainnet.Deserialize(mustHexDecode(t, hexed))
+	w.Nil(err)
+	parsed, err := ParseDelegateEnrollData(decoded.VchData)
+	w.Nil(err).Equal(enroll, *parsed)
+
+	// 签名数据: delegate模版数据(不含类型) + delegate签名
+	tplData := mustHexDecode(t, tplHex)[2:]
+	w.True(bytes.HasPrefix(decoded.SignBytes, tplData))
+	pubk, err := ParsePublicKeyHex(delegate.Pubk)
+	w.Nil(err)
+	hash, err := decoded.TxHash(BBCMainnet)
+	w.Nil(err)
+	w.True(ed25519.Verify(pubk, hash[:], decoded.SignBytes[len(tplData):]))
+
+	_, err = BuildDelegateCertTx(tplHex, 0, nil, BBCMainnet.GenesisAnchor).Build()
+	w.True(err != nil, "enroll data required")
+	_, err = BuildDelegateCertTx("0200"+tplHex[4:], 0, enroll.Bytes(), BBCMainnet.GenesisAnchor).Build()
+	w.True(err != nil, "not delegate template")
+}
+
+func mustHexDecode(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
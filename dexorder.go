@@ -0,0 +1,158 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// dexorder 模版中 price, fee 的精度
+const (
+	DexOrderPriceScale = 10000000000 //DexOrderParam.Price = price * 1e10
+	DexOrderFeeScale   = 10000       //DexOrderParam.Fee = fee * 1e4 (0.002 -> 20)
+)
+
+// DexOrderJSON 与core rpc addnewtemplate dexorder 参数(以及 validateaddress 返回的 dexorder)相同的json格式,
+// price, fee 为小数
+type DexOrderJSON struct {
+	SellerAddress string          `json:"seller_address"`
+	Coinpair      string          `json:"coinpair"`
+	Price         decimal.Decimal `json:"price"`
+	Fee           decimal.Decimal `json:"fee"`
+	RecvAddress   string          `json:"recv_address"`
+	ValidHeight   int32           `json:"valid_height"`
+	MatchAddress  string          `json:"match_address"`
+	DealAddress   string          `json:"deal_address"`
+	Timestamp     uint32          `json:"timestamp,omitempty"`
+}
+
+// ParseDexOrderJSON 解析core addnewtemplate dexorder 格式的json参数
+func ParseDexOrderJSON(b []byte) (DexOrderParam, error) {
+	var j DexOrderJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return DexOrderParam{}, fmt.Errorf("unable to decode dexorder json, %v", err)
+	}
+	return j.Param()
+}
+
+// Param 转换为模版参数, price 最多10位小数, fee 最多4位小数
+func (j DexOrderJSON) Param() (DexOrderParam, error) {
+	price := j.Price.Mul(decimal.New(DexOrderPriceScale, 0))
+	if !price.Equal(price.Truncate(0)) {
+		return DexOrderParam{}, fmt.Errorf("price %s has too many decimal places", j.Price)
+	}
+	fee := j.Fee.Mul(decimal.New(DexOrderFeeScale, 0))
+	if !fee.Equal(fee.Truncate(0)) {
+		return DexOrderParam{}, fmt.Errorf("fee %s has too many decimal places", j.Fee)
+	}
+	p := DexOrderParam{
+		SellerAddress: Address(j.SellerAddress),
+		Coinpair:      j.Coinpair,
+		Price:         price.IntPart(),
+		Fee:           int32(fee.IntPart()),
+		RecvAddress:   j.RecvAddress,
+		ValidHeight:   j.ValidHeight,
+		MatchAddress:  Address(j.MatchAddress),
+		DealAddress:   j.DealAddress,
+		Timestamp:     j.Timestamp,
+	}
+	return p, p.Validate()
+}
+
+// JSON 转换为core格式(price, fee 为小数)
+func (p DexOrderParam) JSON() DexOrderJSON {
+	return DexOrderJSON{
+		SellerAddress: string(p.SellerAddress),
+		Coinpair:      p.Coinpair,
+		Price:         decimal.New(p.Price, 0).Div(decimal.New(DexOrderPriceScale, 0)),
+		Fee:           decimal.New(int64(p.Fee), 0).Div(decimal.New(DexOrderFeeScale, 0)),
+		RecvAddress:   p.RecvAddress,
+		ValidHeight:   p.ValidHeight,
+		MatchAddress:  string(p.MatchAddress),
+		DealAddress:   p.DealAddress,
+		Timestamp:     p.Timestamp,
+	}
+}
+
+// Validate 检查参数: 地址, coinpair 格式(如 bbc/mkf), price > 0, 0 <= fee < 1
+func (p DexOrderParam) Validate() error {
+	for _, a := range []struct {
+		name string
+		addr Address
+	}{{"seller_address", p.SellerAddress}, {"match_address", p.MatchAddress}} {
+		if _, err := NewCDestinationFromAddress(string(a.addr)); err != nil {
+			return fmt.Errorf("invalid %s %s, %v", a.name, a.addr, err)
+		}
+	}
+	if err := ValidateCoinpair(p.Coinpair); err != nil {
+		return err
+	}
+	if p.Price <= 0 {
+		return errors.New("price should be greater than 0")
+	}
+	if p.Fee < 0 || p.Fee >= DexOrderFeeScale {
+		return fmt.Errorf("invalid fee %d, should be in [0, %d)", p.Fee, DexOrderFeeScale)
+	}
+	if p.RecvAddress == "" || p.DealAddress == "" {
+		return errors.New("recv_address and deal_address required")
+	}
+	return nil
+}
+
+// ValidateCoinpair coinpair 格式: 卖出币种/买入币种, 如 bbc/mkf, 币种由字母、数字组成且不能相同
+func ValidateCoinpair(coinpair string) error {
+	parts := strings.Split(coinpair, "/")
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid coinpair %q, should be like bbc/mkf", coinpair)
+	}
+	for _, s := range parts {
+		if s == "" || len(s) > 16 {
+			return fmt.Errorf("invalid coinpair %q, symbol len should be in [1, 16]", coinpair)
+		}
+		for _, c := range s {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+				return fmt.Errorf("invalid coinpair %q, unexpected char %q", coinpair, c)
+			}
+		}
+	}
+	if strings.EqualFold(parts[0], parts[1]) {
+		return fmt.Errorf("invalid coinpair %q, same symbol", coinpair)
+	}
+	return nil
+}
+
+// ParseDexOrderTemplate 解析dexorder模版数据(hex, 含2字节类型),
+// 兼容不包含 timestamp 的旧版本core模版数据(此时 NoTimestamp 为true, 重新编码时保持旧格式)
+func ParseDexOrderTemplate(tplHex string) (*DexOrderParam, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != templateDexorder {
+		return nil, fmt.Errorf("not a dexorder template: %d", typ)
+	}
+	// destSeller << vCoinPair << nPrice << nFee << vRecvDest << nValidHeight << destMatch << destDeal [<< nTimeStamp]
+	r := dataReader{b: data}
+	p := DexOrderParam{
+		SellerAddress: Address(r.destination("seller_address").String()),
+		Coinpair:      string(r.varBytes("coinpair")),
+		Price:         int64(r.uint64("price")),
+		Fee:           int32(r.uint32("fee")),
+		RecvAddress:   string(r.varBytes("recv_address")),
+		ValidHeight:   int32(r.uint32("valid_height")),
+		MatchAddress:  Address(r.destination("match_address").String()),
+		DealAddress:   string(r.varBytes("deal_address")),
+	}
+	if r.err == nil && len(r.b)-r.off == 4 {
+		p.Timestamp = r.uint32("timestamp")
+	} else {
+		p.NoTimestamp = true
+	}
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
@@ -0,0 +1,23 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestDexOrderCoreVector 与当前版本(模版数据包含timestamp)core的 addnewtemplate dexorder 结果比较
+func TestDexOrderCoreVector(t *testing.T) {
+	var v struct {
+		Address  string          `json:"address"`
+		Hex      string          `json:"hex"`
+		DexOrder json.RawMessage `json:"dexorder"`
+	}
+	loadCoreVector(t, "dexorder", &v)
+	w := TW{T: t}
+	p, err := ParseDexOrderJSON(v.DexOrder)
+	w.Nil(err)
+	add, data, err := CreateTemplateDataDexOrder(p)
+	w.Nil(err).Equal(v.Hex, data).Equal(v.Address, add)
+	parsed, err := ParseDexOrderTemplate(v.Hex)
+	w.Nil(err).True(!parsed.NoTimestamp).Equal(p, *parsed)
+}
@@ -1,7 +1,7 @@
 package gobbc
 
 import (
-	"fmt"
+	"strconv"
 )
 
 const base32Alphabet = "0123456789abcdefghjkmnpqrstvwxyz" //base32 words
@@ -62,16 +62,14 @@ func uint256SetHex(hexed string) uint256 {
 	return t
 }
 
+// uint256GetHex 按照uint256的显示顺序(反转)输出hex
 func uint256GetHex(data []byte) string {
-	s := ""
-
+	const hextable = "0123456789abcdef"
+	b := make([]byte, 0, 2*len(data))
 	for i := len(data) - 1; i >= 0; i-- {
-		x := data[i]
-		// for _, x := range data {
-		s += fmt.Sprintf("%02x", uint8(x))
-		fmt.Printf("%d => %02x\n", uint8(x), uint8(x))
+		b = append(b, hextable[data[i]>>4], hextable[data[i]&0x0f])
 	}
-	return s
+	return string(b)
 }
 
 func crc24q(data []uint8, size int) uint {
@@ -83,31 +81,38 @@ func crc24q(data []uint8, size int) uint {
 	return crc & 0x00ffffff
 }
 
-func base32Encode5Bytes(md5 []uint8) string {
-	// fmt.Println("base325bytes in:", md5)
-	ret := ""
-	ret = ret + string(base32Alphabet[(md5[0]>>3)&0x1F])
-	ret = ret + string(base32Alphabet[((md5[0]<<2)&0x1C)|((md5[1]>>6)&0x03)])
-	ret = ret + string(base32Alphabet[(md5[1]>>1)&0x1F])
-	ret = ret + string(base32Alphabet[((md5[1]<<4)&0x10)|((md5[2]>>4)&0x0F)])
-	ret = ret + string(base32Alphabet[((md5[2]<<1)&0x1E)|((md5[3]>>7)&0x01)])
-	ret = ret + string(base32Alphabet[(md5[3]>>2)&0x1F])
-	ret = ret + string(base32Alphabet[((md5[3]<<3)&0x18)|((md5[4]>>5)&0x07)])
-	ret = ret + string(base32Alphabet[(md5[4]&0x1F)])
-	return ret
+// appendBase32Encode5Bytes 5字节编码为8个base32字符
+func appendBase32Encode5Bytes(dst []byte, md5 []uint8) []byte {
+	return append(dst,
+		base32Alphabet[(md5[0]>>3)&0x1F],
+		base32Alphabet[((md5[0]<<2)&0x1C)|((md5[1]>>6)&0x03)],
+		base32Alphabet[(md5[1]>>1)&0x1F],
+		base32Alphabet[((md5[1]<<4)&0x10)|((md5[2]>>4)&0x0F)],
+		base32Alphabet[((md5[2]<<1)&0x1E)|((md5[3]>>7)&0x01)],
+		base32Alphabet[(md5[3]>>2)&0x1F],
+		base32Alphabet[((md5[3]<<3)&0x18)|((md5[4]>>5)&0x07)],
+		base32Alphabet[(md5[4]&0x1F)],
+	)
 }
 
 // Base32Encode copied from c++
 func Base32Encode(md32 []uint8) string {
-	// fmt.Println("base32encode in: ", md32)
+	var buf [56]byte
+	return string(AppendBase32Encode(buf[:0], md32))
+}
+
+// AppendBase32Encode 将32字节数据的base32编码(56个字符，含crc24q校验位)追加到dst
+func AppendBase32Encode(dst []byte, md32 []uint8) []byte {
 	var crc uint = crc24q(md32, 32)
-	// fmt.Printf("crc24q result: %d\n", crc)
-	s := ""
 	for i := 0; i < 30; i = i + 5 {
-		s += base32Encode5Bytes(md32[i : i+5])
+		dst = appendBase32Encode5Bytes(dst, md32[i:i+5])
 	}
-
 	tail := [5]uint8{md32[30], md32[31], (uint8)(crc >> 16), (uint8)(crc >> 8), (uint8)(crc)}
-	s += base32Encode5Bytes(tail[:])
-	return s
+	return appendBase32Encode5Bytes(dst, tail[:])
+}
+
+// AppendAddress 将地址(prefix + base32(data))追加到dst, data 为 CDestination.Data 的字节顺序
+func AppendAddress(dst []byte, prefix uint8, data [32]byte) []byte {
+	dst = strconv.AppendUint(dst, uint64(prefix), 10)
+	return AppendBase32Encode(dst, data[:])
 }
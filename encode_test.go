@@ -0,0 +1,70 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"testing"
+)
+
+// concatBase32Encode 字符串拼接的实现，仅用于对比测试和 benchmark
+func concatBase32Encode(md32 []uint8) string {
+	enc5 := func(md5 []uint8) string {
+		return string(appendBase32Encode5Bytes(nil, md5))
+	}
+	crc := crc24q(md32, 32)
+	s := ""
+	for i := 0; i < 30; i = i + 5 {
+		s += enc5(md32[i : i+5])
+	}
+	tail := [5]uint8{md32[30], md32[31], (uint8)(crc >> 16), (uint8)(crc >> 8), (uint8)(crc)}
+	return s + enc5(tail[:])
+}
+
+func TestAppendAddress(t *testing.T) {
+	w := TW{T: t}
+	for _, addr := range []string{
+		"1z6taz5dyrv2xa11pc92y0ggbrf2wf36gbtk8wjprb96qe3kqwfm3ayc1",
+		"20w09v2efn50pvkncagjb0sxj37e36pfbjjnyzs4zcczy16g7tx7bm6d2",
+	} {
+		dest, err := NewCDestinationFromAddress(addr)
+		w.Nil(err)
+		w.Equal(addr, string(AppendAddress(nil, dest.Prefix, dest.Data)))
+		w.Equal("x"+addr, string(dest.AppendAddress([]byte("x"))))
+		w.Equal(addr[1:], Base32Encode(dest.Data[:]))
+		w.Equal(addr[1:], concatBase32Encode(dest.Data[:]))
+	}
+
+	b, _ := hex.DecodeString("0102")
+	w.Equal("0201", uint256GetHex(b))
+}
+
+func BenchmarkBase32EncodeConcat(b *testing.B) {
+	var data [32]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		concatBase32Encode(data[:])
+	}
+}
+
+func BenchmarkBase32Encode(b *testing.B) {
+	var data [32]byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Base32Encode(data[:])
+	}
+}
+
+func BenchmarkAppendAddress(b *testing.B) {
+	var data [32]byte
+	buf := make([]byte, 0, 57)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf = AppendAddress(buf[:0], PrefixPubk, data)
+	}
+}
+
+func ExampleAppendAddress() {
+	dest, _ := NewCDestinationFromAddress("1z6taz5dyrv2xa11pc92y0ggbrf2wf36gbtk8wjprb96qe3kqwfm3ayc1")
+	fmt.Println(string(AppendAddress(nil, dest.Prefix, dest.Data)))
+	// Output: 1z6taz5dyrv2xa11pc92y0ggbrf2wf36gbtk8wjprb96qe3kqwfm3ayc1
+}
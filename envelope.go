@@ -0,0 +1,316 @@
+package gobbc
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+)
+
+// TXEnvelopeVersion 当前的 TXEnvelope 版本, 版本1为 TXData.EncodeString 的 enc;<tpl>;<tx> 格式
+const TXEnvelopeVersion = 2
+
+// txEnvelopeLegacyVersion enc; 格式的版本, 没有校验和与链信息, 只能解码
+const txEnvelopeLegacyVersion = 1
+
+// TXEnvelope 文本格式的前缀:
+// - tx2: base64url(无padding), 较短
+// - TX2: base32(大写, 无padding), 可使用二维码的alphanumeric模式
+const (
+	txEnvelopeBase64Prefix = "tx2:"
+	txEnvelopeBase32Prefix = "TX2:"
+	txEnvelopeLegacyPrefix = "enc;"
+	txEnvelopeChecksumLen  = 4
+)
+
+var txEnvelopeBase32 = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrTXEnvelopeChecksum 校验和不一致(数据不完整或被修改)
+var ErrTXEnvelopeChecksum = errors.New("tx envelope checksum mismatch")
+
+// TXMeta TXEnvelope 的附加信息
+type TXMeta struct {
+	Creator     string `json:"creator,omitempty"`
+	Expiry      int64  `json:"expiry,omitempty"` //unix 秒, 0表示不过期
+	Description string `json:"description,omitempty"`
+}
+
+// TXEnvelope 带版本、链/分支和校验和的交易数据(含签名需要的模版数据), 用于在钱包/签名机之间传递.
+// 二进制格式(小端, 长度为uint64):
+// |version u8|chain|fork 32|模版数量|[type u16|data]...|tx|meta u8|[creator|expiry u64|description]|checksum 4|,
+// checksum 为之前数据 blake2b-256 的前4字节
+type TXEnvelope struct {
+	Version   int          `json:"version"`
+	Chain     string       `json:"chain"`          //ChainParams.Name, 旧格式为空
+	Fork      string       `json:"fork,omitempty"` //fork id hex(与 Transaction.HashAnchor 格式一致), 不使用anchor的链为空
+	Templates TemplateList `json:"templates,omitempty"`
+	TxHex     string       `json:"tx_hex"`
+	Meta      *TXMeta      `json:"meta,omitempty"`
+}
+
+// NewTXEnvelope 使用链参数编码交易, fork 取自交易的 anchor
+func NewTXEnvelope(params *ChainParams, rtx *RawTransaction, templates TemplateList, meta *TXMeta) (*TXEnvelope, error) {
+	if params == nil {
+		return nil, errors.New("chain params required")
+	}
+	txHex, err := rtx.Encode(params, true)
+	if err != nil {
+		return nil, err
+	}
+	e := TXEnvelope{Version: TXEnvelopeVersion, Chain: params.Name, Templates: templates, TxHex: txHex, Meta: meta}
+	if params.usesAnchor() {
+		e.Fork = CopyReverseThenEncodeHex(rtx.HashAnchorBytes[:])
+	}
+	return &e, nil
+}
+
+// TXData 转换为 TXData
+func (e *TXEnvelope) TXData() TXData {
+	return TXData{TplHex: e.Templates.String(), TxHex: e.TxHex}
+}
+
+// Expired 是否已过期
+func (e *TXEnvelope) Expired(now time.Time) bool {
+	return e.Meta != nil && e.Meta.Expiry > 0 && now.Unix() >= e.Meta.Expiry
+}
+
+// SetChain 设置链参数并使用当前版本: 使用链参数解码交易, Fork 取自交易的 anchor,
+// 旧格式(版本1)没有链信息, 需要设置后才能编码
+func (e *TXEnvelope) SetChain(params *ChainParams) error {
+	if params == nil {
+		return errors.New("chain params required")
+	}
+	tx, err := DecodeRawTransaction(params, e.TxHex, true)
+	if err != nil {
+		return err
+	}
+	e.Version, e.Chain, e.Fork = TXEnvelopeVersion, params.Name, ""
+	if params.usesAnchor() {
+		e.Fork = tx.HashAnchor
+	}
+	return nil
+}
+
+// RawTransaction 使用 Chain 对应的链参数解码交易, 并校验 anchor 与 Fork 一致
+func (e *TXEnvelope) RawTransaction() (*RawTransaction, error) {
+	if e.Chain == "" {
+		return nil, errors.New("tx envelope chain not set, see SetChain")
+	}
+	params, err := GetChainParams(e.Chain)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := DecodeRawTransaction(params, e.TxHex, true)
+	if err != nil {
+		return nil, err
+	}
+	if e.Fork != "" && tx.HashAnchor != strings.ToLower(e.Fork) {
+		return nil, fmt.Errorf("tx anchor %s does not match fork %s", tx.HashAnchor, e.Fork)
+	}
+	return &tx.RawTransaction, nil
+}
+
+// payload 不含校验和的二进制数据, 旧格式或没有链信息时返回错误(参考 SetChain)
+func (e *TXEnvelope) payload() ([]byte, error) {
+	if e.Version == txEnvelopeLegacyVersion {
+		return nil, errors.New("legacy tx envelope can not be encoded, see SetChain")
+	}
+	if e.Version != TXEnvelopeVersion {
+		return nil, fmt.Errorf("unsupported tx envelope version %d", e.Version)
+	}
+	if e.Chain == "" {
+		return nil, errors.New("tx envelope chain required, see SetChain")
+	}
+	var fork [32]byte
+	if e.Fork != "" {
+		h, err := decodeHash(e.Fork)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid fork")
+		}
+		fork = h
+	}
+	tx, err := hex.DecodeString(e.TxHex)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid tx hex")
+	}
+	b := []byte{uint8(e.Version)}
+	b = appendVarBytes(b, []byte(e.Chain))
+	b = append(b, fork[:]...)
+	b = appendUint64(b, uint64(len(e.Templates)))
+	for _, t := range e.Templates {
+		b = appendVarBytes(appendUint16(b, uint16(t.Type)), t.Data)
+	}
+	b = appendVarBytes(b, tx)
+	if e.Meta == nil {
+		return append(b, 0), nil
+	}
+	b = appendVarBytes(append(b, 1), []byte(e.Meta.Creator))
+	b = appendUint64(b, uint64(e.Meta.Expiry))
+	return appendVarBytes(b, []byte(e.Meta.Description)), nil
+}
+
+func txEnvelopeChecksum(payload []byte) []byte {
+	h := blake2b.Sum256(payload)
+	return h[:txEnvelopeChecksumLen]
+}
+
+// Bytes 二进制格式
+func (e *TXEnvelope) Bytes() ([]byte, error) {
+	b, err := e.payload()
+	if err != nil {
+		return nil, err
+	}
+	return append(b, txEnvelopeChecksum(b)...), nil
+}
+
+// Checksum 校验和(hex)
+func (e *TXEnvelope) Checksum() (string, error) {
+	b, err := e.payload()
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(txEnvelopeChecksum(b)), nil
+}
+
+// ParseTXEnvelopeBytes 解析二进制格式并校验
+func ParseTXEnvelopeBytes(b []byte) (*TXEnvelope, error) {
+	if len(b) < 1+txEnvelopeChecksumLen {
+		return nil, ErrTruncated
+	}
+	payload, sum := b[:len(b)-txEnvelopeChecksumLen], b[len(b)-txEnvelopeChecksumLen:]
+	if !bytes.Equal(sum, txEnvelopeChecksum(payload)) {
+		return nil, ErrTXEnvelopeChecksum
+	}
+	r := dataReader{b: payload}
+	e := TXEnvelope{Version: int(r.uint8("version"))}
+	if r.err == nil && e.Version != TXEnvelopeVersion {
+		return nil, fmt.Errorf("unsupported tx envelope version %d", e.Version)
+	}
+	if e.Chain = string(r.varBytes("chain")); r.err == nil && e.Chain == "" {
+		return nil, errors.New("tx envelope chain required")
+	}
+	if fork := r.hash("fork"); fork != ([32]byte{}) {
+		e.Fork = CopyReverseThenEncodeHex(fork[:])
+	}
+	n := r.uint64("templates")
+	if r.err == nil && n > uint64(len(payload)) {
+		return nil, fmt.Errorf("read templates err, %v", ErrTruncated)
+	}
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		typ := TemplateType(r.uint16("template type"))
+		e.Templates = append(e.Templates, Template{Type: typ, Data: r.varBytes("template data")})
+	}
+	e.TxHex = hex.EncodeToString(r.varBytes("tx"))
+	switch flag := r.uint8("meta"); {
+	case r.err != nil, flag == 0:
+	case flag == 1:
+		e.Meta = &TXMeta{
+			Creator:     string(r.varBytes("creator")),
+			Expiry:      int64(r.uint64("expiry")),
+			Description: string(r.varBytes("description")),
+		}
+	default:
+		return nil, fmt.Errorf("invalid tx envelope meta flag %d", flag)
+	}
+	if err := r.close(); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// EncodeBase64 base64url 文本格式: tx2:...
+func (e *TXEnvelope) EncodeBase64() (string, error) {
+	b, err := e.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return txEnvelopeBase64Prefix + base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// EncodeBase32 base32 文本格式: TX2:..., 只包含大写字母和数字, 适合二维码
+func (e *TXEnvelope) EncodeBase32() (string, error) {
+	b, err := e.Bytes()
+	if err != nil {
+		return "", err
+	}
+	return txEnvelopeBase32Prefix + txEnvelopeBase32.EncodeToString(b), nil
+}
+
+// DecodeTXEnvelope 解析 tx2:(base64url), TX2:(base32), json 以及旧的 enc;<tpl>;<tx> 格式
+// (没有校验和, Version 为1, Chain, Fork 为空, 使用 SetChain 设置链后才能编码)
+func DecodeTXEnvelope(s string) (*TXEnvelope, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case strings.HasPrefix(s, txEnvelopeLegacyPrefix):
+		var data TXData
+		if err := data.decodeLegacy(s); err != nil {
+			return nil, err
+		}
+		templates, err := data.Templates()
+		if err != nil {
+			return nil, err
+		}
+		return &TXEnvelope{Version: txEnvelopeLegacyVersion, Templates: templates, TxHex: data.TxHex}, nil
+	case strings.HasPrefix(s, txEnvelopeBase64Prefix):
+		b, err := base64.RawURLEncoding.DecodeString(s[len(txEnvelopeBase64Prefix):])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid base64 tx envelope")
+		}
+		return ParseTXEnvelopeBytes(b)
+	case strings.HasPrefix(s, txEnvelopeBase32Prefix):
+		b, err := txEnvelopeBase32.DecodeString(s[len(txEnvelopeBase32Prefix):])
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid base32 tx envelope")
+		}
+		return ParseTXEnvelopeBytes(b)
+	case strings.HasPrefix(s, "{"):
+		var e TXEnvelope
+		if err := json.Unmarshal([]byte(s), &e); err != nil {
+			return nil, err
+		}
+		return &e, nil
+	}
+	return nil, errors.New("unknown tx envelope format")
+}
+
+type txEnvelopeJSON TXEnvelope
+
+// MarshalJSON 包含校验和
+func (e TXEnvelope) MarshalJSON() ([]byte, error) {
+	sum, err := e.Checksum()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(struct {
+		txEnvelopeJSON
+		Checksum string `json:"checksum"`
+	}{txEnvelopeJSON(e), sum})
+}
+
+// UnmarshalJSON 校验 checksum
+func (e *TXEnvelope) UnmarshalJSON(b []byte) error {
+	var v struct {
+		txEnvelopeJSON
+		Checksum string `json:"checksum"`
+	}
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	x := TXEnvelope(v.txEnvelopeJSON)
+	sum, err := x.Checksum()
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(sum, v.Checksum) {
+		return ErrTXEnvelopeChecksum
+	}
+	*e = x
+	return nil
+}
@@ -0,0 +1,105 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTXEnvelope(t *testing.T) {
+	w := TW{T: t}
+	keys := make([]AddrKeyPair, 2)
+	for i := range keys {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys[i] = k
+	}
+	multisigTpl := testMultisigTplHex(t, 2, keys[0].Pubk, keys[1].Pubk)
+	templates, err := ParseTemplateList(multisigTpl)
+	w.Nil(err)
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+		SetAddress(keys[0].Addr).
+		SetAmount(1).SetFee(0.01).
+		Build()
+	w.Nil(err)
+	w.Nil(rtx.SignWithTemplates(BBCSerializer, templates, keys[0].Privk))
+
+	meta := &TXMeta{Creator: "wallet-1", Expiry: 1590478315, Description: "payout"}
+	e, err := NewTXEnvelope(BBCMainnet, rtx, templates, meta)
+	w.Nil(err).Equal(TXEnvelopeVersion, e.Version).Equal("bbc", e.Chain).Equal(BBCMainnet.GenesisAnchor, e.Fork)
+	w.True(!e.Expired(time.Unix(1590474715, 0))).True(e.Expired(time.Unix(1590478315, 0)))
+
+	b64, err := e.EncodeBase64()
+	w.Nil(err).True(strings.HasPrefix(b64, "tx2:"))
+	b32, err := e.EncodeBase32()
+	w.Nil(err).True(strings.HasPrefix(b32, "TX2:")).Equal(strings.ToUpper(b32), b32)
+	js, err := json.Marshal(e)
+	w.Nil(err).True(strings.Contains(string(js), `"checksum":"`), string(js))
+
+	for _, s := range []string{b64, b32, string(js), " " + b64 + "\n"} {
+		got, err := DecodeTXEnvelope(s)
+		w.Nil(err, s).Equal(e, got, s)
+		decoded, err := got.RawTransaction()
+		w.Nil(err).Equal(rtx.SignBytes, decoded.SignBytes)
+
+		var data TXData
+		w.Nil(data.DecodeString(s)).Equal(e.TXData(), data)
+	}
+
+	// 旧格式
+	data := e.TXData()
+	legacy, err := data.EncodeString()
+	w.Nil(err)
+	got, err := DecodeTXEnvelope(legacy)
+	w.Nil(err).Equal(1, got.Version).Equal(templates, got.Templates).Equal(e.TxHex, got.TxHex)
+	w.Equal("", got.Chain).Equal("", got.Fork)
+	// 设置链之前不能编码
+	_, err = got.EncodeBase64()
+	w.True(err != nil, "legacy")
+	_, err = json.Marshal(got)
+	w.True(err != nil, "legacy")
+	_, err = got.RawTransaction()
+	w.True(err != nil, "chain not set")
+	w.True(got.SetChain(MKFMainnet) != nil, "tx not decodable with mkf params")
+	w.Nil(got.SetChain(BBCMainnet))
+	upgraded := *e
+	upgraded.Meta = nil //旧格式没有meta
+	w.Equal(&upgraded, got)
+	_, err = got.EncodeBase64()
+	w.Nil(err)
+	chainless := *e
+	chainless.Chain = ""
+	_, err = chainless.Bytes()
+	w.True(err != nil, "chain required")
+
+	// meta 标记只能为0或1
+	payload, err := got.payload()
+	w.Nil(err).Equal(byte(0), payload[len(payload)-1])
+	payload[len(payload)-1] = 2
+	_, err = ParseTXEnvelopeBytes(append(payload, txEnvelopeChecksum(payload)...))
+	w.True(err != nil && err != ErrTXEnvelopeChecksum, err)
+
+	// 截断、修改
+	_, err = DecodeTXEnvelope(b64[:len(b64)-3])
+	w.True(err != nil, "truncated")
+	mid := len(b64) / 2
+	c := 'A'
+	if b64[mid] == 'A' {
+		c = 'B'
+	}
+	_, err = DecodeTXEnvelope(b64[:mid] + string(c) + b64[mid+1:])
+	w.True(err == ErrTXEnvelopeChecksum, err)
+	_, err = DecodeTXEnvelope(strings.Replace(string(js), `"payout"`, `"payout!"`, 1))
+	w.True(err == ErrTXEnvelopeChecksum, err)
+	_, err = DecodeTXEnvelope("tx1:abc")
+	w.True(err != nil, "format")
+
+	other := *e
+	other.Fork = "00000065f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2"
+	_, err = other.RawTransaction()
+	w.True(err != nil, "fork mismatch")
+}
@@ -0,0 +1,217 @@
+package gobbc
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// ExchangeTemplate 跨分支原子交换模版, 双方分别在 ForkM, ForkS 上向同一个模版地址转入资金:
+// - ForkM 上的资金在 HeightM 之前由 SpendS 提取(需要双方对模版的签名 vsm, vss), 之后由 SpendM 赎回
+// - ForkS 上的资金在 HeightS 之前由 SpendM 提取(同样需要 vsm, vss), 之后由 SpendS 赎回
+// SpendS 在 ForkM 上提取时会公开 vss, SpendM 据此在 ForkS 上提取, 因此 HeightS 应大于 HeightM 留出时间
+// |---33---|---33---|---4---|---4---|---32---|---32---|
+// | spendM | spendS |heightM|heightS| forkM  | forkS  |
+type ExchangeTemplate struct {
+	SpendM  CDestination
+	SpendS  CDestination
+	HeightM int32
+	HeightS int32
+	ForkM   string //fork id hex
+	ForkS   string //fork id hex
+}
+
+func (tpl *ExchangeTemplate) data() ([]byte, error) {
+	if tpl.SpendM.Prefix != PrefixPubk || tpl.SpendS.Prefix != PrefixPubk {
+		return nil, errors.New("exchange spend address should be pubkey address")
+	}
+	forkM, err := decodeHash(tpl.ForkM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fork m, %v", err)
+	}
+	forkS, err := decodeHash(tpl.ForkS)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fork s, %v", err)
+	}
+	if forkM == forkS {
+		return nil, errors.New("exchange forks should be different")
+	}
+	b := make([]byte, 0, 2*destinationLen+8+2*uint256Len)
+	b = append(b, tpl.SpendM.Bytes()...)
+	b = append(b, tpl.SpendS.Bytes()...)
+	b = appendUint32(b, uint32(tpl.HeightM))
+	b = appendUint32(b, uint32(tpl.HeightS))
+	b = append(b, forkM[:]...)
+	return append(b, forkS[:]...), nil
+}
+
+// CreateTemplateDataExchange 创建交换模版, 返回 模版地址, 模版数据hex
+func CreateTemplateDataExchange(tpl ExchangeTemplate) (string, string, error) {
+	data, err := tpl.data()
+	if err != nil {
+		return "", "", err
+	}
+	addr, tplHex := encodeTemplate(TemplateTypeExchange, data)
+	return addr, tplHex, nil
+}
+
+// ParseExchangeTemplateHex 解析交换模版数据
+func ParseExchangeTemplateHex(tplHex string) (*ExchangeTemplate, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypeExchange {
+		return nil, fmt.Errorf("not an exchange template: %s", typ)
+	}
+	r := dataReader{b: data}
+	tpl := ExchangeTemplate{
+		SpendM:  r.destination("spendM"),
+		SpendS:  r.destination("spendS"),
+		HeightM: int32(r.uint32("heightM")),
+		HeightS: int32(r.uint32("heightS")),
+	}
+	forkM, forkS := r.hash("forkM"), r.hash("forkS")
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	tpl.ForkM = CopyReverseThenEncodeHex(forkM[:])
+	tpl.ForkS = CopyReverseThenEncodeHex(forkS[:])
+	return &tpl, nil
+}
+
+// exchangeTemplateID 双方签名的内容: 模版id(模版地址的32字节数据),
+// 签名内容与 SignExchange 的签名数据格式使用core的数据在 TestExchangeCoreVector 中验证
+func exchangeTemplateID(tplHex string) (*ExchangeTemplate, []byte, error) {
+	tpl, err := ParseExchangeTemplateHex(tplHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, nil, err
+	}
+	id := templateDestination(TemplateTypeExchange, data)
+	return tpl, id.Data[:], nil
+}
+
+// SignExchangeTemplate 交换参与方对模版签名(SpendM 的签名为 vsm, SpendS 的签名为 vss), 返回签名hex,
+// 通常 SpendM 先将 vsm 交给 SpendS
+func SignExchangeTemplate(tplHex, privkHex string) (string, error) {
+	_, id, err := exchangeTemplateID(tplHex)
+	if err != nil {
+		return "", err
+	}
+	privk, err := ParsePrivkHex(privkHex)
+	if err != nil {
+		return "", fmt.Errorf("unable to parse private key, %v", err)
+	}
+	return hex.EncodeToString(ed25519.Sign(privk, id)), nil
+}
+
+// VerifyExchangeSignatures 验证 vsm, vss 分别为 SpendM, SpendS 对模版的签名
+func VerifyExchangeSignatures(tplHex, vsm, vss string) error {
+	tpl, id, err := exchangeTemplateID(tplHex)
+	if err != nil {
+		return err
+	}
+	for _, x := range []struct {
+		name string
+		dest CDestination
+		sig  string
+	}{{"vsm", tpl.SpendM, vsm}, {"vss", tpl.SpendS, vss}} {
+		sig, err := hex.DecodeString(x.sig)
+		if err != nil {
+			return fmt.Errorf("invalid %s hex, %v", x.name, err)
+		}
+		if !ed25519.Verify(ed25519.PublicKey(x.dest.Data[:]), id, sig) {
+			return fmt.Errorf("invalid %s signature", x.name)
+		}
+	}
+	return nil
+}
+
+// SignExchange 从交换模版提取资金(对方分支的资金): 在 ForkM 上由 SpendS 签名, 在 ForkS 上由 SpendM 签名,
+// 签名数据: | 模版数据 | vsm(vector) | vss(vector) | 交易签名(vector) |, 参考core CTemplateExchange 的 ds >> vsm >> vss >> vchSig,
+// 根据tx anchor确定提取的分支, 不使用anchor的链(如MKF)需要在签名前将anchor设置为提取的分支id,
+// 超过期限后的赎回使用 rtx.SignWithPrivateKey(serializer, tplHex, privk)
+// 注意：离线环境下无法检查高度，调用方需确保在期限之前广播
+func (rtx *RawTransaction) SignExchange(serializer Serializer, tplHex, vsm, vss, privkHex string) error {
+	if len(rtx.SignBytes) > 0 {
+		return errors.New("seems tx already signed")
+	}
+	tpl, err := ParseExchangeTemplateHex(tplHex)
+	if err != nil {
+		return err
+	}
+	if err = VerifyExchangeSignatures(tplHex, vsm, vss); err != nil {
+		return err
+	}
+	privk, err := ParsePrivkHex(privkHex)
+	if err != nil {
+		return fmt.Errorf("unable to parse private key, %v", err)
+	}
+	pubk := privk.Public().(ed25519.PublicKey)
+
+	// 在 ForkM 上只有 SpendS 可以提取, 在 ForkS 上只有 SpendM 可以提取
+	var expected CDestination
+	switch anchor := CopyReverseThenEncodeHex(rtx.HashAnchorBytes[:]); {
+	case rtx.HashAnchorBytes == [32]byte{}:
+		return errors.New("tx anchor required to determine the exchange fork, set it to fork m or fork s")
+	case anchor == tpl.ForkM:
+		expected = tpl.SpendS
+	case anchor == tpl.ForkS:
+		expected = tpl.SpendM
+	default:
+		return fmt.Errorf("tx anchor %s is neither fork m nor fork s", anchor)
+	}
+	if !bytes.Equal(pubk, expected.Data[:]) {
+		return fmt.Errorf("private key does not match %s", expected)
+	}
+
+	txHash, err := rtx.TxHash(serializer)
+	if err != nil {
+		return fmt.Errorf("calculate tx hash failed, %v", err)
+	}
+	sm, err := hex.DecodeString(vsm)
+	if err != nil {
+		return fmt.Errorf("invalid vsm hex, %v", err)
+	}
+	ss, err := hex.DecodeString(vss)
+	if err != nil {
+		return fmt.Errorf("invalid vss hex, %v", err)
+	}
+	tplBytes, err := hex.DecodeString(tplHex)
+	if err != nil {
+		return fmt.Errorf("invalid template hex, %v", err)
+	}
+	b := append([]byte{}, tplBytes[2:]...)
+	b = appendVarBytes(b, sm)
+	b = appendVarBytes(b, ss)
+	rtx.SignBytes = appendVarBytes(b, ed25519.Sign(privk, txHash[:]))
+	rtx.SizeSign = uint64(len(rtx.SignBytes))
+	return nil
+}
+
+// ParseExchangeSignature 从提取交易的签名数据中解析 vsm, vss(hex),
+// 对方在一个分支上提取后, 可以据此获取 vss 并在另一个分支上提取
+func ParseExchangeSignature(tplHex string, signBytes []byte) (vsm, vss string, err error) {
+	tplBytes, err := hex.DecodeString(tplHex)
+	if err != nil || len(tplBytes) < 2 {
+		return "", "", errors.New("invalid template hex")
+	}
+	if !bytes.HasPrefix(signBytes, tplBytes[2:]) {
+		return "", "", errors.New("sign data does not start with template data")
+	}
+	r := dataReader{b: signBytes[len(tplBytes)-2:]}
+	sm, ss, sig := r.varBytes("vsm"), r.varBytes("vss"), r.varBytes("sig")
+	if err = r.close(); err != nil {
+		return "", "", err
+	}
+	if len(sig) != ed25519.SignatureSize {
+		return "", "", errors.New("invalid signature length")
+	}
+	return hex.EncodeToString(sm), hex.EncodeToString(ss), nil
+}
@@ -0,0 +1,148 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestExchangeTemplate(t *testing.T) {
+	w := TW{T: t}
+	const forkS = "00000065f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2"
+	m, err := MakeKeyPair()
+	w.Nil(err)
+	s, err := MakeKeyPair()
+	w.Nil(err)
+	destM, err := NewCDestinationFromAddress(m.Addr)
+	w.Nil(err)
+	destS, err := NewCDestinationFromAddress(s.Addr)
+	w.Nil(err)
+
+	tpl := ExchangeTemplate{SpendM: destM, SpendS: destS, HeightM: 1000, HeightS: 1200, ForkM: BBCMainnet.GenesisAnchor, ForkS: forkS}
+	addr, tplHex, err := CreateTemplateDataExchange(tpl)
+	w.Nil(err).True(strings.HasPrefix(addr, "20r0"), addr) //模版类型6
+	w.Equal(2+33+33+4+4+32+32, len(tplHex)/2)
+	tplAddr, err := TemplateAddress(tplHex)
+	w.Nil(err).Equal(addr, tplAddr)
+	parsed, err := ParseExchangeTemplateHex(tplHex)
+	w.Nil(err).Equal(tpl, *parsed)
+
+	bad := tpl
+	bad.ForkS = bad.ForkM
+	_, _, err = CreateTemplateDataExchange(bad)
+	w.True(err != nil, "same fork")
+	bad = tpl
+	bad.SpendM = CDestination{Prefix: PrefixTemplate}
+	_, _, err = CreateTemplateDataExchange(bad)
+	w.True(err != nil, "template spend")
+}
+
+// 离线完成两个分支上的提取
+func TestExchangeSwap(t *testing.T) {
+	w := TW{T: t}
+	const forkS = "00000065f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2"
+	m, err := MakeKeyPair()
+	w.Nil(err)
+	s, err := MakeKeyPair()
+	w.Nil(err)
+	destM, err := NewCDestinationFromAddress(m.Addr)
+	w.Nil(err)
+	destS, err := NewCDestinationFromAddress(s.Addr)
+	w.Nil(err)
+	_, tplHex, err := CreateTemplateDataExchange(ExchangeTemplate{
+		SpendM: destM, SpendS: destS, HeightM: 1000, HeightS: 1200, ForkM: BBCMainnet.GenesisAnchor, ForkS: forkS,
+	})
+	w.Nil(err)
+
+	build := func(anchor, to string) *RawTransaction {
+		rtx, err := NewTXBuilder().
+			SetAnchor(anchor).
+			SetTimestamp(1590474715).
+			AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+			SetAddress(to).
+			SetAmount(100).SetFee(0.01).
+			Build()
+		w.Nil(err)
+		return rtx
+	}
+
+	// m 将 vsm 交给 s
+	vsm, err := SignExchangeTemplate(tplHex, m.Privk)
+	w.Nil(err)
+	vss, err := SignExchangeTemplate(tplHex, s.Privk)
+	w.Nil(err)
+	w.Nil(VerifyExchangeSignatures(tplHex, vsm, vss))
+	w.True(VerifyExchangeSignatures(tplHex, vss, vsm) != nil, "swapped")
+
+	// s 在 ForkM 上提取
+	legM := build(BBCMainnet.GenesisAnchor, s.Addr)
+	w.True(legM.SignExchange(BBCSerializer, tplHex, vsm, vss, m.Privk) != nil, "m can not claim on fork m")
+	w.Nil(legM.SignExchange(BBCSerializer, tplHex, vsm, vss, s.Privk))
+	hexed, err := legM.Encode(BBCSerializer, true)
+	w.Nil(err)
+	decoded, err := BBCSerializer.Deserialize(mustHexDecode(t, hexed))
+	w.Nil(err)
+
+	// m 从 ForkM 上的交易获取 vss, 在 ForkS 上提取
+	gotVsm, gotVss, err := ParseExchangeSignature(tplHex, decoded.SignBytes)
+	w.Nil(err).Equal(vsm, gotVsm).Equal(vss, gotVss)
+	legS := build(forkS, m.Addr)
+	w.True(legS.SignExchange(BBCSerializer, tplHex, gotVsm, gotVss, s.Privk) != nil, "s can not claim on fork s")
+	w.Nil(legS.SignExchange(BBCSerializer, tplHex, gotVsm, gotVss, m.Privk))
+	w.True(legS.SignExchange(BBCSerializer, tplHex, gotVsm, gotVss, m.Privk) != nil, "already signed")
+
+	// 签名的结构: 模版数据 + vsm + vss + 交易签名, 均为vector
+	tplData := mustHexDecode(t, tplHex)[2:]
+	w.Equal(len(tplData)+3*(8+64), len(legS.SignBytes))
+	sig, err := ParseSignature(TemplateList{{Type: TemplateTypeExchange, Data: tplData}}, legS.SignBytes[len(tplData):])
+	w.Nil(err).Equal(SignatureExchange, sig.Kind).Equal(gotVss, hex.EncodeToString(sig.VSS))
+	legS.SignBytes, legS.SizeSign = nil, 0
+	w.True(legS.SignExchange(BBCSerializer, tplHex, gotVsm+"x", gotVss, m.Privk) != nil, "invalid vsm hex")
+
+	other := build("0000001e5d1a4c6f3c8d7a2b9e0f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b", m.Addr)
+	w.True(other.SignExchange(BBCSerializer, tplHex, vsm, vss, m.Privk) != nil, "unknown fork")
+	other.HashAnchorBytes = [32]byte{}
+	w.True(other.SignExchange(BBCSerializer, tplHex, vsm, vss, m.Privk) != nil, "anchor required")
+	w.True(other.SignExchange(BBCSerializer, tplHex, vsm, vss, s.Privk) != nil, "anchor required")
+
+	// 超时赎回, 普通签名
+	refund := build(BBCMainnet.GenesisAnchor, m.Addr)
+	w.Nil(refund.SignWithPrivateKey(BBCSerializer, tplHex, m.Privk))
+	_, _, err = ParseExchangeSignature(tplHex, refund.SignBytes)
+	w.True(err != nil)
+}
+
+// TestExchangeCoreVector 与core CTemplateExchange 的结果逐字节比较: vsm/vss 的签名内容以及提取交易的签名数据
+func TestExchangeCoreVector(t *testing.T) {
+	var v struct {
+		MPrivkey string `json:"m_privkey"`
+		SPrivkey string `json:"s_privkey"`
+		TplHex   string `json:"tpl_hex"`
+		Address  string `json:"address"`
+		Vsm      string `json:"vsm"`
+		Vss      string `json:"vss"`
+		TxHex    string `json:"tx_hex"` //core 签名后的提取交易
+	}
+	loadCoreVector(t, "exchange", &v)
+	w := TW{T: t}
+	addr, err := TemplateAddress(v.TplHex)
+	w.Nil(err).Equal(v.Address, addr)
+	vsm, err := SignExchangeTemplate(v.TplHex, v.MPrivkey)
+	w.Nil(err).Equal(v.Vsm, vsm)
+	vss, err := SignExchangeTemplate(v.TplHex, v.SPrivkey)
+	w.Nil(err).Equal(v.Vss, vss)
+
+	tx, err := DecodeRawTransaction(BBCSerializer, v.TxHex, true)
+	w.Nil(err)
+	rtx := tx.RawTransaction
+	gotVsm, gotVss, err := ParseExchangeSignature(v.TplHex, rtx.SignBytes)
+	w.Nil(err).Equal(v.Vsm, gotVsm).Equal(v.Vss, gotVss)
+	rtx.SignBytes, rtx.SizeSign = nil, 0
+	privk := v.SPrivkey
+	if tpl, err := ParseExchangeTemplateHex(v.TplHex); err == nil && CopyReverseThenEncodeHex(rtx.HashAnchorBytes[:]) == tpl.ForkS {
+		privk = v.MPrivkey
+	}
+	w.Nil(rtx.SignExchange(BBCSerializer, v.TplHex, v.Vsm, v.Vss, privk))
+	hexed, err := rtx.Encode(BBCSerializer, true)
+	w.Nil(err).Equal(v.TxHex, hexed)
+}
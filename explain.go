@@ -0,0 +1,279 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// highFeeMultiple 手续费超过最低手续费的倍数时提示风险
+const highFeeMultiple = 10
+
+// RiskCode 交易风险, 值稳定, 可用于本地化
+type RiskCode string
+
+// 交易风险
+const (
+	RiskVoteDestination     RiskCode = "vote_destination"     //转账地址为投票模版, 转入的币用于投票
+	RiskTemplateDestination RiskCode = "template_destination" //转账地址为其他模版(非投票)
+	RiskDestinationRule     RiskCode = "destination_rule"     //转账地址不符合链的地址规则
+	RiskFeeHigh             RiskCode = "fee_high"             //手续费远高于最低手续费
+	RiskFeeLow              RiskCode = "fee_low"              //手续费低于最低手续费
+	RiskUnknownFork         RiskCode = "unknown_fork"         //anchor 不是已知分支
+	RiskLocked              RiskCode = "lock_until"           //设置了 LockUntil
+	RiskTemplateUnresolved  RiskCode = "template_unresolved"  //无法获取需要附加的模版数据
+	RiskSenderUnresolved    RiskCode = "sender_unresolved"    //已签名交易的签名数据无法确定转出地址(如模版数据格式有歧义)
+)
+
+// Risk 风险及其参数(用于格式化本地化的文本)
+type Risk struct {
+	Code RiskCode
+	Args []interface{}
+}
+
+// Memo 交易的 vchData
+type Memo struct {
+	Format string //vchdata: 通用vchData格式, text: utf8文本, hex: 二进制
+	Desc   string //vchdata 的数据格式描述
+	UUID   string //vchdata
+	Time   time.Time
+	Text   string //Data 为utf8文本时
+	Data   []byte
+}
+
+// Explanation 交易的结构化说明, 用于签名前确认; 文本使用 Text 按语言生成
+type Explanation struct {
+	Chain      string
+	Symbol     string //币种符号, 用作 Amount, Fee 的单位
+	TxType     TxType
+	ForkID     string
+	ForkName   string //未知分支时为空
+	From       string //未知时为空
+	To         string
+	ToTemplate TemplateType //转账地址的模版类型, 公钥地址为 TemplateTypeMin
+	Amount     decimal.Decimal
+	Fee        decimal.Decimal
+	MinFee     decimal.Decimal
+	Memo       *Memo //没有 vchData 时为nil
+	LockUntil  uint32
+	Inputs     []Vin
+	Templates  TemplateList //签名时附加的模版数据(已签名时从签名数据解析, 未签名时通过resolver获取)
+	Risks      []Risk
+}
+
+// Explain 生成交易说明, from 未知时使用, 参考 ExplainFrom
+func Explain(rtx *RawTransaction, params *ChainParams, resolver TemplateDataResolver) (*Explanation, error) {
+	return ExplainFrom(rtx, params, "", resolver)
+}
+
+// ExplainFrom 生成交易说明, from: 转出地址, 未签名时为空则无法给出from的模版数据, 已签名时用于确定签名数据中的模版类型;
+// resolver 用于获取未签名交易需要附加的模版数据(参考 ResolveSignTemplates), 可为nil
+func ExplainFrom(rtx *RawTransaction, params *ChainParams, from string, resolver TemplateDataResolver) (*Explanation, error) {
+	if rtx == nil || params == nil {
+		return nil, errors.New("tx and chain params required")
+	}
+	tx := rtx.ToTransaction(false)
+	to := CDestination{Prefix: rtx.Prefix, Data: rtx.AddressBytes}
+	e := Explanation{
+		Chain:      params.Name,
+		Symbol:     params.Symbol,
+		TxType:     rtx.TxType(),
+		ForkID:     tx.HashAnchor,
+		ForkName:   params.ForkName(tx.HashAnchor),
+		From:       from,
+		To:         tx.Address,
+		ToTemplate: to.TemplateType(),
+		Amount:     params.ToCoin(rtx.Amount),
+		Fee:        params.ToCoin(rtx.TxFee),
+		MinFee:     params.ToCoin(params.EstimateFee(rtx)),
+		LockUntil:  rtx.LockUntil,
+		Inputs:     tx.Vin,
+	}
+	if len(rtx.VchData) > 0 {
+		e.Memo = explainMemo(rtx.VchData)
+	}
+
+	if e.ToTemplate == TemplateTypeVote {
+		e.addRisk(RiskVoteDestination, e.To)
+	} else if e.ToTemplate != TemplateTypeMin {
+		e.addRisk(RiskTemplateDestination, e.ToTemplate.String())
+	}
+	if err := params.Address.ValidateDestination(rtx.Prefix, rtx.AddressBytes); err != nil {
+		e.addRisk(RiskDestinationRule, err.Error())
+	}
+	if !rtx.TxType().IsMint() {
+		minFee := params.EstimateFee(rtx)
+		if rtx.TxFee < minFee {
+			e.addRisk(RiskFeeLow, e.Fee.String(), e.MinFee.String())
+		} else if rtx.TxFee > minFee*highFeeMultiple {
+			e.addRisk(RiskFeeHigh, e.Fee.String(), e.MinFee.String())
+		}
+	}
+	if params.usesAnchor() && e.ForkName == "" {
+		e.addRisk(RiskUnknownFork, e.ForkID)
+	}
+	if rtx.LockUntil > 0 {
+		e.addRisk(RiskLocked, rtx.LockUntil)
+	}
+
+	if e.Symbol == "" {
+		e.Symbol = strings.ToUpper(params.Name)
+	}
+
+	if len(rtx.SignBytes) > 0 {
+		if e.From == "" {
+			var err error
+			if e.From, err = rtx.SenderAddress(nil); err != nil && !errors.Is(err, ErrSenderUnknown) {
+				e.addRisk(RiskSenderUnresolved, err.Error())
+			}
+		}
+		if tpls, _, err := ParseSignData(rtx.SignBytes, e.To, e.From); err == nil {
+			e.Templates = tpls
+		}
+	} else if from != "" {
+		tpls, err := ResolveSignTemplates(rtx, from, resolver)
+		if err != nil {
+			e.addRisk(RiskTemplateUnresolved, from)
+		} else if e.Templates, err = ParseTemplateList(tpls); err != nil {
+			e.addRisk(RiskTemplateUnresolved, from)
+		}
+	} else if e.ToTemplate == TemplateTypeVote {
+		tpl, err := resolveTemplate(resolver, to)
+		if err != nil {
+			e.addRisk(RiskTemplateUnresolved, e.To)
+		} else if t, err := ParseTemplate(tpl); err == nil {
+			e.Templates = TemplateList{t}
+		}
+	}
+	return &e, nil
+}
+
+func (e *Explanation) addRisk(code RiskCode, args ...interface{}) {
+	e.Risks = append(e.Risks, Risk{Code: code, Args: args})
+}
+
+// explainMemo 优先按通用vchData格式解析, 其次为utf8文本, 否则为二进制
+func explainMemo(b []byte) *Memo {
+	if vd, err := ParseVchData(b); err == nil {
+		if desc, err := vd.DataFmtDesc(); err == nil && utf8.ValidString(desc) {
+			m := Memo{Format: "vchdata", Desc: desc, UUID: vd.UUID().String(), Time: vd.Time(), Data: vd.Data()}
+			if utf8.Valid(m.Data) {
+				m.Text = string(m.Data)
+			}
+			return &m
+		}
+	}
+	m := Memo{Format: "hex", Data: b}
+	if utf8.Valid(b) {
+		m.Format, m.Text = "text", string(b)
+	}
+	return &m
+}
+
+// ExplainMessages 各语言的字段名称和风险提示(fmt格式, 参数为 Risk.Args), 可以添加或替换
+var ExplainMessages = map[string]map[string]string{
+	"en": {
+		"type": "Type", "fork": "Fork", "from": "From", "to": "To", "amount": "Amount", "fee": "Fee", "memo": "Memo",
+		"lock_until": "Lock until", "inputs": "Inputs", "templates": "Templates", "risks": "Risks",
+		"unknown": "unknown", "pubkey": "pubkey",
+		riskKey(RiskVoteDestination):     "destination %s is a vote template, the amount will be used for voting",
+		riskKey(RiskTemplateDestination): "destination is a %s template",
+		riskKey(RiskDestinationRule):     "destination not allowed on this chain: %s",
+		riskKey(RiskFeeHigh):             "fee %s is unusually high (min fee %s)",
+		riskKey(RiskFeeLow):              "fee %s is lower than min fee %s",
+		riskKey(RiskUnknownFork):         "unknown fork %s",
+		riskKey(RiskLocked):              "outputs locked until height %d",
+		riskKey(RiskTemplateUnresolved):  "template data of %s unavailable",
+		riskKey(RiskSenderUnresolved):    "unable to determine sender from sign data: %s",
+	},
+	"zh": {
+		"type": "类型", "fork": "分支", "from": "转出地址", "to": "转账地址", "amount": "金额", "fee": "手续费", "memo": "附加数据",
+		"lock_until": "锁定至", "inputs": "输入", "templates": "模版数据", "risks": "风险",
+		"unknown": "未知", "pubkey": "公钥",
+		riskKey(RiskVoteDestination):     "转账地址 %s 为投票模版, 转入的币将用于投票",
+		riskKey(RiskTemplateDestination): "转账地址为 %s 模版",
+		riskKey(RiskDestinationRule):     "转账地址不符合链的规则: %s",
+		riskKey(RiskFeeHigh):             "手续费 %s 异常偏高(最低手续费 %s)",
+		riskKey(RiskFeeLow):              "手续费 %s 低于最低手续费 %s",
+		riskKey(RiskUnknownFork):         "未知分支 %s",
+		riskKey(RiskLocked):              "输出锁定至高度 %d",
+		riskKey(RiskTemplateUnresolved):  "无法获取 %s 的模版数据",
+		riskKey(RiskSenderUnresolved):    "无法从签名数据确定转出地址: %s",
+	},
+}
+
+func riskKey(code RiskCode) string { return "risk." + string(code) }
+
+func explainMessage(lang, key string) string {
+	if s, ok := ExplainMessages[lang][key]; ok {
+		return s
+	}
+	if s, ok := ExplainMessages["en"][key]; ok {
+		return s
+	}
+	return key
+}
+
+// Message 风险提示文本, 未知语言使用en
+func (r Risk) Message(lang string) string {
+	return fmt.Sprintf(explainMessage(lang, riskKey(r.Code)), r.Args...)
+}
+
+// Text 纯文本格式的交易说明, lang: en, zh, 或 ExplainMessages 中添加的语言
+func (e *Explanation) Text(lang string) string {
+	var sb strings.Builder
+	line := func(key, format string, args ...interface{}) {
+		fmt.Fprintf(&sb, "%s: "+format+"\n", append([]interface{}{explainMessage(lang, key)}, args...)...)
+	}
+	line("type", "%s", e.TxType)
+	fork := e.ForkName
+	if fork == "" {
+		fork = explainMessage(lang, "unknown")
+	}
+	line("fork", "%s %s", fork, e.ForkID)
+	toType := explainMessage(lang, "pubkey")
+	if e.ToTemplate != TemplateTypeMin {
+		toType = e.ToTemplate.String()
+	}
+	if e.From != "" {
+		line("from", "%s", e.From)
+	}
+	line("to", "%s (%s)", e.To, toType)
+	line("amount", "%s %s", e.Amount.String(), e.Symbol)
+	line("fee", "%s %s", e.Fee.String(), e.Symbol)
+	if m := e.Memo; m != nil {
+		switch {
+		case m.Text != "" && m.Desc != "":
+			line("memo", "[%s %s] %s", m.Format, m.Desc, m.Text)
+		case m.Text != "":
+			line("memo", "[%s] %s", m.Format, m.Text)
+		default:
+			line("memo", "[%s] %s", m.Format, hex.EncodeToString(m.Data))
+		}
+	}
+	if e.LockUntil > 0 {
+		line("lock_until", "%d", e.LockUntil)
+	}
+	line("inputs", "%d", len(e.Inputs))
+	for _, in := range e.Inputs {
+		fmt.Fprintf(&sb, "  %s:%d\n", in.Txid, in.Vout)
+	}
+	if len(e.Templates) > 0 {
+		line("templates", "%d", len(e.Templates))
+		for _, t := range e.Templates {
+			fmt.Fprintf(&sb, "  %s %s\n", t.Type, t.Address())
+		}
+	}
+	if len(e.Risks) > 0 {
+		line("risks", "%d", len(e.Risks))
+		for _, r := range e.Risks {
+			fmt.Fprintf(&sb, "  ! %s\n", r.Message(lang))
+		}
+	}
+	return sb.String()
+}
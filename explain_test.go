@@ -0,0 +1,97 @@
+package gobbc
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	w := TW{T: t}
+	key, err := MakeKeyPair()
+	w.Nil(err)
+	owner, err := NewCDestinationFromAddress(key.Addr)
+	w.Nil(err)
+	delegateAddr, delegateTpl, err := CreateTemplateDataDelegate(key.Pubk, owner)
+	w.Nil(err)
+	delegateDest, err := NewCDestinationFromAddress(delegateAddr)
+	w.Nil(err)
+	voteAddr, voteTpl, err := CreateTemplateDataVote(VoteTpl{Delegate: delegateDest, Voter: owner})
+	w.Nil(err)
+
+	const input = "5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782"
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput(input, 1).
+		SetAddress(key.Addr).
+		SetAmount(1.5).SetFee(0.03).
+		SetData("text", []byte("hello")).
+		Build()
+	w.Nil(err)
+
+	e, err := Explain(rtx, BBCMainnet, nil)
+	w.Nil(err)
+	w.Equal("bbc", e.Chain).Equal(TxTypeToken, e.TxType).Equal(MainForkName, e.ForkName).Equal(key.Addr, e.To)
+	w.Equal(TemplateTypeMin, e.ToTemplate).Equal("1.5", e.Amount.String()).Equal("0.03", e.Fee.String())
+	w.Equal("vchdata", e.Memo.Format).Equal("text", e.Memo.Desc).Equal("hello", e.Memo.Text)
+	w.Equal([]Vin{{Txid: input, Vout: 1}}, e.Inputs).Equal(0, len(e.Risks), e.Risks)
+	text := e.Text("en")
+	w.True(strings.Contains(text, "Amount: 1.5 BBC"), text).True(strings.Contains(text, "Fee: 0.03 BBC"), text)
+	w.True(strings.Contains(text, "Memo: [vchdata text] hello"), text).True(!strings.Contains(text, "From:"), text)
+	w.True(strings.Contains(e.Text("zh"), "金额: 1.5 BBC"))
+
+	// from 为模版地址, 通过 resolver 获取签名时附加的模版数据
+	e, err = ExplainFrom(rtx, BBCMainnet, delegateAddr, nil)
+	w.Nil(err).Equal(0, len(e.Templates)).Equal(1, len(e.Risks)).Equal(RiskTemplateUnresolved, e.Risks[0].Code)
+	e, err = ExplainFrom(rtx, BBCMainnet, delegateAddr, TemplateResolverFunc(func(address string) (string, error) {
+		return delegateTpl, nil
+	}))
+	w.Nil(err).Equal(delegateTpl, e.Templates.String()).Equal(0, len(e.Risks))
+	text = e.Text("en")
+	w.True(strings.Contains(text, "From: "+delegateAddr), text).True(strings.Contains(text, "delegate "+delegateAddr), text)
+
+	// 已签名, delegate与proof的模版数据格式相同, 需要给出from
+	signed := *rtx
+	w.Nil(signed.SignWithPrivateKey(BBCSerializer, delegateTpl, key.Privk))
+	e, err = Explain(&signed, BBCMainnet, nil)
+	w.Nil(err).Equal("", e.From).Equal(0, len(e.Templates)).Equal(1, len(e.Risks)).Equal(RiskSenderUnresolved, e.Risks[0].Code)
+	e, err = ExplainFrom(&signed, BBCMainnet, delegateAddr, nil)
+	w.Nil(err).Equal(delegateAddr, e.From).Equal(delegateTpl, e.Templates.String()).Equal(0, len(e.Risks))
+
+	// 投票, 手续费偏高, 未知分支, 锁定
+	rtx, err = NewTXBuilder().
+		SetAnchor("00000065f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2").
+		SetTimestamp(1590474715).
+		SetLockUntil(100).
+		AddInput(input, 0).
+		SetAddress(voteAddr).
+		SetAmount(10).SetFee(1).
+		Build()
+	w.Nil(err)
+	e, err = Explain(rtx, BBCMainnet, nil)
+	w.Nil(err)
+	var codes []RiskCode
+	for _, r := range e.Risks {
+		codes = append(codes, r.Code)
+	}
+	w.Equal([]RiskCode{RiskVoteDestination, RiskFeeHigh, RiskUnknownFork, RiskLocked, RiskTemplateUnresolved}, codes)
+	w.Equal("fee 1 is unusually high (min fee 0.01)", e.Risks[1].Message("en"))
+	w.Equal("输出锁定至高度 100", e.Risks[3].Message("zh")).Equal(e.Risks[3].Message("en"), e.Risks[3].Message("fr"))
+
+	resolver, err := NewMemoryTemplateResolver(voteTpl, delegateTpl)
+	w.Nil(err)
+	e, err = Explain(rtx, BBCMainnet, resolver)
+	w.Nil(err).Equal(voteTpl, e.Templates.String()).Equal(4, len(e.Risks))
+	text = e.Text("en")
+	w.True(strings.Contains(text, "vote "+voteAddr), text).True(strings.Contains(text, "! destination "+voteAddr+" is a vote template"), text)
+
+	e, err = ExplainFrom(rtx, BBCMainnet, key.Addr, resolver)
+	w.Nil(err).Equal(voteTpl, e.Templates.String()).Equal(key.Addr, e.From)
+
+	w.Nil(rtx.SignWithPrivateKey(BBCSerializer, voteTpl, key.Privk))
+	e, err = Explain(rtx, BBCMainnet, nil)
+	w.Nil(err).Equal(voteTpl, e.Templates.String())
+
+	_, err = Explain(rtx, nil, nil)
+	w.True(err != nil)
+}
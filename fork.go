@@ -0,0 +1,199 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// MainForkName 主链(创世块)分支名称
+const MainForkName = "main"
+
+// ForkInfo 分支信息
+type ForkInfo struct {
+	Name  string `json:"name"`
+	ID    string `json:"id"`    //fork id (hex, 与 Transaction.HashAnchor 格式一致)
+	Chain string `json:"chain"` //所属链，ChainParams.Name
+}
+
+// ForkRegistry 已知的分支(fork id)注册表
+type ForkRegistry struct {
+	mu   sync.RWMutex
+	byID map[string]ForkInfo
+}
+
+// NewForkRegistry .
+func NewForkRegistry() *ForkRegistry {
+	return &ForkRegistry{byID: map[string]ForkInfo{}}
+}
+
+// DefaultForkRegistry 默认注册表，包含已知链的主链分支，未指定 ChainParams.Forks 时使用
+var DefaultForkRegistry = NewForkRegistry()
+
+func init() {
+	for _, p := range []*ChainParams{BBCMainnet, BBCTestnet, MKFMainnet} {
+		if p.GenesisAnchor == "" {
+			continue
+		}
+		if err := DefaultForkRegistry.Register(ForkInfo{Name: MainForkName, ID: p.GenesisAnchor, Chain: p.Name}); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// RegisterFork 注册分支到 DefaultForkRegistry
+func RegisterFork(f ForkInfo) error { return DefaultForkRegistry.Register(f) }
+
+// LoadForks 从json配置加载分支到 DefaultForkRegistry
+func LoadForks(r io.Reader) error { return DefaultForkRegistry.Load(r) }
+
+// Register 注册分支，同一个fork id 不能注册为不同的分支
+func (r *ForkRegistry) Register(f ForkInfo) error {
+	id, err := normalizeForkID(f.ID)
+	if err != nil {
+		return err
+	}
+	if f.Name == "" || f.Chain == "" {
+		return errors.New("fork name and chain required")
+	}
+	f.ID = id
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if old, ok := r.byID[id]; ok && old != f {
+		return fmt.Errorf("fork %s already registered as %s(%s)", id, old.Name, old.Chain)
+	}
+	r.byID[id] = f
+	return nil
+}
+
+// Load 加载json配置: [{"name": "xxx", "id": "fork id hex", "chain": "bbc"}]
+func (r *ForkRegistry) Load(rd io.Reader) error {
+	var forks []ForkInfo
+	if err := json.NewDecoder(rd).Decode(&forks); err != nil {
+		return errors.Wrap(err, "failed to decode fork config")
+	}
+	for _, f := range forks {
+		if err := r.Register(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadFile 从json文件加载，格式参考 Load
+func (r *ForkRegistry) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return r.Load(f)
+}
+
+// Lookup 根据fork id查询
+func (r *ForkRegistry) Lookup(id string) (ForkInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.byID[strings.ToLower(id)]
+	return f, ok
+}
+
+// Forks 某条链上已注册的分支，按名称排序
+func (r *ForkRegistry) Forks(chain string) []ForkInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var ret []ForkInfo
+	for _, f := range r.byID {
+		if f.Chain == chain {
+			ret = append(ret, f)
+		}
+	}
+	sort.Slice(ret, func(i, j int) bool { return ret[i].Name < ret[j].Name })
+	return ret
+}
+
+func normalizeForkID(id string) (string, error) {
+	b, err := hex.DecodeString(id)
+	if err != nil {
+		return "", fmt.Errorf("invalid fork id %s, %v", id, err)
+	}
+	if len(b) != 32 {
+		return "", fmt.Errorf("invalid fork id %s, len should be 32", id)
+	}
+	return strings.ToLower(id), nil
+}
+
+func (p *ChainParams) forks() *ForkRegistry {
+	if p.Forks != nil {
+		return p.Forks
+	}
+	return DefaultForkRegistry
+}
+
+// usesAnchor 序列化时是否包含anchor(MKF不包含)
+func (p *ChainParams) usesAnchor() bool {
+	s, err := streamConfig(p.Serializer)
+	return err != nil || s.includeAnchor
+}
+
+// ForkName anchor(fork id hex)对应的分支名称，未知时返回空字符串;
+// 不使用anchor的链(如MKF)始终为主链
+func (p *ChainParams) ForkName(anchor string) string {
+	if !p.usesAnchor() {
+		return MainForkName
+	}
+	if f, ok := p.forks().Lookup(anchor); ok && f.Chain == p.Name {
+		return f.Name
+	}
+	return ""
+}
+
+// ErrUnknownFork anchor不是该链已注册的分支(或该链没有注册任何分支, 如未注册创世块的测试网)
+var ErrUnknownFork = errors.New("unknown fork")
+
+// ValidateAnchor 检查anchor是否属于该链:
+// 不使用anchor的链不检查; 否则anchor不能为空，且必须是该链已注册的分支之一, 否则返回 ErrUnknownFork
+func (p *ChainParams) ValidateAnchor(anchor [32]byte) error {
+	if !p.usesAnchor() {
+		return nil
+	}
+	if anchor == ([32]byte{}) {
+		return errors.New("fork id (anchor) not provided")
+	}
+	id := CopyReverseThenEncodeHex(anchor[:])
+	forks := p.forks().Forks(p.Name)
+	if len(forks) == 0 {
+		return errors.Wrapf(ErrUnknownFork, "no fork registered on chain %s, anchor %s", p.Name, id)
+	}
+	for _, f := range forks {
+		if f.ID == id {
+			return nil
+		}
+	}
+	return errors.Wrapf(ErrUnknownFork, "%s on chain %s", id, p.Name)
+}
+
+// forkName 按serializer确定anchor对应的分支名称, serializer 可以是 *ChainParams 或 ChainParams;
+// 其他serializer不使用anchor时为主链, 否则查询 DefaultForkRegistry
+func forkName(serializer Serializer, anchor string) string {
+	switch p := serializer.(type) {
+	case *ChainParams:
+		return p.ForkName(anchor)
+	case ChainParams:
+		return p.ForkName(anchor)
+	}
+	if s, err := streamConfig(serializer); err == nil && !s.includeAnchor {
+		return MainForkName
+	}
+	if f, ok := DefaultForkRegistry.Lookup(anchor); ok {
+		return f.Name
+	}
+	return ""
+}
@@ -0,0 +1,72 @@
+package gobbc
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestForkRegistry(t *testing.T) {
+	w := TW{T: t}
+	f, ok := DefaultForkRegistry.Lookup(BBCMainnet.GenesisAnchor)
+	w.True(ok).Equal(ForkInfo{Name: MainForkName, ID: BBCMainnet.GenesisAnchor, Chain: "bbc"}, f)
+
+	r := NewForkRegistry()
+	w.Nil(r.Load(strings.NewReader(`[
+		{"name": "main", "id": "00000000b0a9be545f022309e148894d1e1c853ccac3ef04cb6f5e5c70f41a70", "chain": "bbc"},
+		{"name": "user-fork", "id": "0000001E5D1A4C6F3C8D7A2B9E0F1C2D3E4F5A6B7C8D9E0F1A2B3C4D5E6F7A8B", "chain": "bbc"}
+	]`)))
+	f, ok = r.Lookup("0000001e5d1a4c6f3c8d7a2b9e0f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b")
+	w.True(ok).Equal("user-fork", f.Name)
+	w.Equal(2, len(r.Forks("bbc"))).Equal(0, len(r.Forks("mkf")))
+
+	w.True(r.Register(ForkInfo{Name: "other", ID: f.ID, Chain: "bbc"}) != nil, "conflict")
+	w.True(r.Register(ForkInfo{Name: "bad", ID: "00", Chain: "bbc"}) != nil, "invalid id")
+	w.True(r.Load(strings.NewReader(`{}`)) != nil)
+}
+
+func TestBuildValidateAnchor(t *testing.T) {
+	w := TW{T: t}
+	forks := NewForkRegistry()
+	w.Nil(forks.Register(ForkInfo{Name: "main", ID: BBCMainnet.GenesisAnchor, Chain: "bbc"}))
+	w.Nil(forks.Register(ForkInfo{Name: "user-fork", ID: "0000001e5d1a4c6f3c8d7a2b9e0f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b", Chain: "bbc"}))
+	params := *BBCMainnet
+	params.Forks = forks
+
+	build := func(p *ChainParams, anchor string) (*RawTransaction, error) {
+		b := NewTXBuilder().SetChainParams(p)
+		if anchor != "" {
+			b.SetAnchor(anchor)
+		}
+		return b.SetTimestamp(1590474715).
+			AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1).
+			SetAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm").
+			SetAmount(1.23).SetFee(0.01).
+			Build()
+	}
+
+	rtx, err := build(&params, "0000001e5d1a4c6f3c8d7a2b9e0f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b")
+	w.Nil(err)
+	hexed, err := rtx.Encode(&params, false)
+	w.Nil(err)
+	tx, err := DecodeRawTransaction(&params, hexed, false)
+	w.Nil(err).Equal("user-fork", tx.ForkName)
+	tx, err = DecodeRawTransaction(params, hexed, false)
+	w.Nil(err).Equal("user-fork", tx.ForkName)
+
+	_, err = build(BBCMainnet, "0000001e5d1a4c6f3c8d7a2b9e0f1c2d3e4f5a6b7c8d9e0f1a2b3c4d5e6f7a8b")
+	w.True(err != nil, "fork not registered in default registry")
+	_, err = build(&params, "")
+	w.True(err != nil, "anchor required")
+	_, err = build(BBCTestnet, BBCMainnet.GenesisAnchor)
+	w.True(errors.Is(err, ErrUnknownFork), "no fork registered on testnet", err)
+	rtx, err = build(MKFMainnet, "")
+	w.Nil(err)
+	hexed, err = rtx.Encode(MKFSerializer, false)
+	w.Nil(err)
+	tx, err = DecodeRawTransaction(MKFSerializer, hexed, false)
+	w.Nil(err).Equal(MainForkName, tx.ForkName)
+
+	tx, err = DecodeRawTransaction(BBCSerializer, "01000000dbb7cc5e00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b0000000000182e7a2ae807032941897bd7e01a3221b91cdb63f0a2d64dcad937c9f98e3c55e01017c755b96a15a57a7253d2bf80a1d9c4ca84a9a70da6ab77ab96661fc7b7193cfb0c412000000000010270000000000000000", false)
+	w.Nil(err).Equal(MainForkName, tx.ForkName)
+}
@@ -0,0 +1,185 @@
+package gobbc
+
+import (
+	"fmt"
+	"time"
+)
+
+// LintSeverity 检查结果的严重程度
+type LintSeverity int
+
+// 严重程度, SeverityOff 用于在 LintOptions.Severity 中关闭规则
+const (
+	SeverityOff LintSeverity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+func (s LintSeverity) String() string {
+	switch s {
+	case SeverityOff:
+		return "off"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+	return fmt.Sprintf("LintSeverity(%d)", int(s))
+}
+
+// LintRule 检查规则ID, 值稳定
+type LintRule string
+
+// 检查规则
+const (
+	LintFeeLow         LintRule = "fee-low"         //手续费低于链的最低手续费
+	LintFeeHigh        LintRule = "fee-high"        //手续费超过最低手续费的 MaxFeeMultiple 倍
+	LintDust           LintRule = "dust-amount"     //金额低于 DustAmount
+	LintDataSize       LintRule = "data-size"       //vchData 超过 MaxDataSize
+	LintLockPast       LintRule = "lock-past"       //LockUntil 不大于当前高度(锁定无效)
+	LintLockFar        LintRule = "lock-far"        //LockUntil 超过当前高度 MaxLockBlocks
+	LintTimestampDrift LintRule = "timestamp-drift" //Timestamp 与当前时间相差超过 MaxTimeDrift
+	LintDuplicateInput LintRule = "duplicate-input" //重复的输入
+	LintZeroAnchor     LintRule = "zero-anchor"     //使用anchor的链(如BBC) anchor 为空
+	LintUnknownVersion LintRule = "unknown-version" //tx版本与链的默认版本不同
+)
+
+// lintDefaultSeverity 各规则默认的严重程度
+var lintDefaultSeverity = map[LintRule]LintSeverity{
+	LintFeeLow:         SeverityError,
+	LintFeeHigh:        SeverityWarning,
+	LintDust:           SeverityWarning,
+	LintDataSize:       SeverityError,
+	LintLockPast:       SeverityInfo,
+	LintLockFar:        SeverityWarning,
+	LintTimestampDrift: SeverityError,
+	LintDuplicateInput: SeverityError,
+	LintZeroAnchor:     SeverityError,
+	LintUnknownVersion: SeverityWarning,
+}
+
+// 检查的默认参数
+const (
+	defaultLintMaxDataSize   = 4096
+	defaultLintMaxTimeDrift  = 10 * time.Minute
+	defaultLintMaxLockBlocks = 365 * 24 * 3600 / forkBlockSpacing //约1年
+)
+
+// LintOptions 检查参数, 零值使用默认值
+type LintOptions struct {
+	Params         *ChainParams //为空时使用 BBCMainnet
+	Now            time.Time    //为零时使用当前时间
+	Height         uint32       //当前高度, 为0时不检查 LockUntil
+	MaxFeeMultiple int64        //默认10
+	DustAmount     int64        //最小单位, 默认为链的 MinTxFee
+	MaxDataSize    int          //默认4096
+	MaxTimeDrift   time.Duration
+	MaxLockBlocks  uint32                    //默认约1年的区块数
+	Severity       map[LintRule]LintSeverity //覆盖规则的严重程度, SeverityOff 关闭规则
+}
+
+// Finding 检查结果
+type Finding struct {
+	Rule     LintRule
+	Severity LintSeverity
+	Message  string
+}
+
+func (f Finding) String() string { return fmt.Sprintf("%s [%s] %s", f.Severity, f.Rule, f.Message) }
+
+// Lint 使用可配置的规则检查交易(签名前, 广播前), 返回按规则顺序排列的检查结果
+func Lint(rtx *RawTransaction, opts LintOptions) []Finding {
+	opts = opts.withDefaults()
+	p := opts.Params
+	var findings []Finding
+	add := func(rule LintRule, format string, args ...interface{}) {
+		severity, ok := opts.Severity[rule]
+		if !ok {
+			severity = lintDefaultSeverity[rule]
+		}
+		if severity == SeverityOff {
+			return
+		}
+		findings = append(findings, Finding{Rule: rule, Severity: severity, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if !rtx.TxType().IsMint() {
+		minFee := p.EstimateFee(rtx)
+		if rtx.TxFee < minFee {
+			add(LintFeeLow, "fee %s lower than min fee %s", p.ToCoin(rtx.TxFee), p.ToCoin(minFee))
+		} else if rtx.TxFee > minFee*opts.MaxFeeMultiple {
+			add(LintFeeHigh, "fee %s more than %d times of min fee %s", p.ToCoin(rtx.TxFee), opts.MaxFeeMultiple, p.ToCoin(minFee))
+		}
+		if rtx.Amount < opts.DustAmount {
+			add(LintDust, "amount %s lower than %s", p.ToCoin(rtx.Amount), p.ToCoin(opts.DustAmount))
+		}
+	}
+	if len(rtx.VchData) > opts.MaxDataSize {
+		add(LintDataSize, "data size %d exceeds %d", len(rtx.VchData), opts.MaxDataSize)
+	}
+	if opts.Height > 0 && rtx.LockUntil > 0 {
+		if rtx.LockUntil <= opts.Height {
+			add(LintLockPast, "lock until %d not after current height %d", rtx.LockUntil, opts.Height)
+		} else if rtx.LockUntil-opts.Height > opts.MaxLockBlocks {
+			add(LintLockFar, "lock until %d is %d blocks after current height %d", rtx.LockUntil, rtx.LockUntil-opts.Height, opts.Height)
+		}
+	}
+	if drift := time.Unix(int64(rtx.Timestamp), 0).Sub(opts.Now); drift > opts.MaxTimeDrift || -drift > opts.MaxTimeDrift {
+		add(LintTimestampDrift, "timestamp %d differs from now by %s", rtx.Timestamp, drift.Round(time.Second))
+	}
+	//直接遍历 Input, 手工构造的交易 SizeIn 可能与 Input 不一致
+	seen := map[[33]byte]bool{}
+	for off := 0; off+33 <= len(rtx.Input); off += 33 {
+		var in [33]byte
+		copy(in[:], rtx.Input[off:off+33])
+		if seen[in] {
+			add(LintDuplicateInput, "duplicate input %s:%d", CopyReverseThenEncodeHex(in[:32]), in[32])
+		}
+		seen[in] = true
+	}
+	if p.usesAnchor() && rtx.HashAnchorBytes == ([32]byte{}) {
+		add(LintZeroAnchor, "anchor required on chain %s", p.Name)
+	}
+	if p.DefaultTxVersion != 0 && rtx.Version != p.DefaultTxVersion {
+		add(LintUnknownVersion, "tx version %d, expected %d on chain %s", rtx.Version, p.DefaultTxVersion, p.Name)
+	}
+	return findings
+}
+
+// HasSeverity 检查结果中是否有不低于 severity 的项, 用于决定是否拦截交易
+func HasSeverity(findings []Finding, severity LintSeverity) bool {
+	for _, f := range findings {
+		if f.Severity >= severity {
+			return true
+		}
+	}
+	return false
+}
+
+func (opts LintOptions) withDefaults() LintOptions {
+	if opts.Params == nil {
+		opts.Params = BBCMainnet
+	}
+	if opts.Now.IsZero() {
+		opts.Now = time.Now()
+	}
+	if opts.MaxFeeMultiple <= 0 {
+		opts.MaxFeeMultiple = highFeeMultiple
+	}
+	if opts.DustAmount == 0 {
+		opts.DustAmount = opts.Params.Fee.MinTxFee
+	}
+	if opts.MaxDataSize <= 0 {
+		opts.MaxDataSize = defaultLintMaxDataSize
+	}
+	if opts.MaxTimeDrift <= 0 {
+		opts.MaxTimeDrift = defaultLintMaxTimeDrift
+	}
+	if opts.MaxLockBlocks == 0 {
+		opts.MaxLockBlocks = defaultLintMaxLockBlocks
+	}
+	return opts
+}
@@ -0,0 +1,76 @@
+package gobbc
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLint(t *testing.T) {
+	w := TW{T: t}
+	const (
+		input = "5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782"
+		to    = "1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm"
+	)
+	now := time.Unix(1590474715, 0)
+	rules := func(findings []Finding) []LintRule {
+		var ret []LintRule
+		for _, f := range findings {
+			ret = append(ret, f.Rule)
+		}
+		return ret
+	}
+
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(int(now.Unix())).
+		AddInput(input, 0).
+		SetAddress(to).
+		SetAmount(1).SetFee(0.01).
+		Build()
+	w.Nil(err)
+	opts := LintOptions{Now: now, Height: 1000}
+	w.Equal(0, len(Lint(rtx, opts)), Lint(rtx, opts))
+
+	rtx, err = NewTXBuilder().
+		SetTimestamp(int(now.Unix())+3600).
+		SetLockUntil(100).
+		SetVersion(3).
+		AddInput(input, 0).AddInput(input, 0).
+		SetAddress(to).
+		SetAmount(0.001).SetFee(0.001).
+		SetRawData(make([]byte, 5000)).
+		Build()
+	w.Nil(err)
+	findings := Lint(rtx, opts)
+	w.Equal([]LintRule{LintFeeLow, LintDust, LintDataSize, LintLockPast, LintTimestampDrift, LintDuplicateInput, LintZeroAnchor, LintUnknownVersion}, rules(findings))
+	w.True(HasSeverity(findings, SeverityError))
+	w.Equal(SeverityInfo, findings[3].Severity)
+	w.True(strings.HasPrefix(findings[0].String(), "error [fee-low] fee 0.001 lower than min fee"), findings[0].String())
+
+	opts.Severity = map[LintRule]LintSeverity{LintFeeLow: SeverityOff, LintDataSize: SeverityOff, LintTimestampDrift: SeverityWarning, LintDuplicateInput: SeverityOff, LintZeroAnchor: SeverityOff}
+	opts.Params = MKFMainnet
+	findings = Lint(rtx, opts)
+	w.Equal([]LintRule{LintDust, LintLockPast, LintTimestampDrift, LintUnknownVersion}, rules(findings))
+	w.True(!HasSeverity(findings, SeverityError))
+
+	rtx, err = NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(int(now.Unix())).
+		SetLockUntil(1000+600000).
+		AddInput(input, 0).
+		SetAddress(to).
+		SetAmount(1).SetFee(1).
+		Build()
+	w.Nil(err)
+	w.Equal([]LintRule{LintFeeHigh, LintLockFar}, rules(Lint(rtx, LintOptions{Now: now, Height: 1000})))
+	w.Equal([]LintRule{LintLockFar}, rules(Lint(rtx, LintOptions{Now: now, Height: 1000, MaxFeeMultiple: 200})))
+
+	// 手工构造的交易 SizeIn 与 Input 不一致时不应panic
+	malformed := *rtx
+	malformed.SizeIn = 3
+	malformed.Input = append(append(append([]byte{}, rtx.Input...), rtx.Input...), 1, 2, 3)
+	findings = Lint(&malformed, LintOptions{Now: now, Height: 1000, MaxFeeMultiple: 200})
+	w.Equal([]LintRule{LintLockFar, LintDuplicateInput}, rules(findings))
+	w.True(strings.Contains(findings[1].Message, input+":0"), findings[1].Message)
+}
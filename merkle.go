@@ -0,0 +1,178 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// MerkleProof tx在区块merkle树中的证明
+type MerkleProof struct {
+	Index  uint32   `json:"index"`  //tx在区块vtx中的位置
+	Branch []string `json:"branch"` //从叶子到根每一层的兄弟节点hash(hex, 与txid格式相同)
+}
+
+// merkleHash 父节点hash: blake2b(left || right), 内存字节序
+func merkleHash(left, right [32]byte) [32]byte {
+	var b [64]byte
+	copy(b[:32], left[:])
+	copy(b[32:], right[:])
+	return blake2b.Sum256(b[:])
+}
+
+// buildMerkleTree 参考core CBlock::BuildMerkleTree, 返回所有层的节点(叶子在前, 根在最后),
+// 某一层节点数为奇数时最后一个节点与自身组合
+func buildMerkleTree(leaves [][32]byte) [][32]byte {
+	tree := append(make([][32]byte, 0, 2*len(leaves)), leaves...)
+	j := 0
+	for size := len(leaves); size > 1; size = (size + 1) / 2 {
+		for i := 0; i < size; i += 2 {
+			i2 := i + 1
+			if i2 > size-1 {
+				i2 = size - 1
+			}
+			tree = append(tree, merkleHash(tree[j+i], tree[j+i2]))
+		}
+		j += size
+	}
+	return tree
+}
+
+func decodeHash(s string) ([32]byte, error) {
+	var h [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, fmt.Errorf("invalid hash %s, %v", s, err)
+	}
+	if len(b) != 32 {
+		return h, fmt.Errorf("invalid hash %s, len should be 32", s)
+	}
+	copy(h[:], reverseBytes(b))
+	return h, nil
+}
+
+func decodeHashes(hashes []string) ([][32]byte, error) {
+	ret := make([][32]byte, len(hashes))
+	for i, s := range hashes {
+		h, err := decodeHash(s)
+		if err != nil {
+			return nil, err
+		}
+		ret[i] = h
+	}
+	return ret, nil
+}
+
+func merkleRoot(leaves [][32]byte) [32]byte {
+	if len(leaves) == 0 {
+		return [32]byte{}
+	}
+	tree := buildMerkleTree(leaves)
+	return tree[len(tree)-1]
+}
+
+// MerkleRoot 计算txid列表(区块vtx的顺序, 不含铸币tx)的merkle root(hex), 列表为空时为全0
+func MerkleRoot(txids []string) (string, error) {
+	leaves, err := decodeHashes(txids)
+	if err != nil {
+		return "", err
+	}
+	root := merkleRoot(leaves)
+	return CopyReverseThenEncodeHex(root[:]), nil
+}
+
+// MerkleRoot 区块头中的merkle root(hex)
+func (block *Block) MerkleRoot() string {
+	return CopyReverseThenEncodeHex(block.HashMerkle[:])
+}
+
+// UpdateMerkleRoot 根据 Vtx 计算并设置 HashMerkle
+func (block *Block) UpdateMerkleRoot() error {
+	leaves, err := block.txHashes()
+	if err != nil {
+		return err
+	}
+	block.HashMerkle = merkleRoot(leaves)
+	return nil
+}
+
+func (block *Block) txHashes() ([][32]byte, error) {
+	txids, err := block.Txids()
+	if err != nil {
+		return nil, err
+	}
+	return decodeHashes(txids)
+}
+
+// BuildMerkleProof 构造txid在区块中的merkle证明
+func BuildMerkleProof(block *Block, txid string) (*MerkleProof, error) {
+	target, err := decodeHash(txid)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := block.txHashes()
+	if err != nil {
+		return nil, err
+	}
+	index := -1
+	for i, h := range leaves {
+		if h == target {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return nil, fmt.Errorf("tx %s not found in block", txid)
+	}
+
+	proof := &MerkleProof{Index: uint32(index)}
+	tree := buildMerkleTree(leaves)
+	j := 0
+	for size := len(leaves); size > 1; size = (size + 1) / 2 {
+		sibling := index ^ 1
+		if sibling > size-1 {
+			sibling = size - 1
+		}
+		proof.Branch = append(proof.Branch, CopyReverseThenEncodeHex(tree[j+sibling][:]))
+		index >>= 1
+		j += size
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof 验证txid包含在merkle root为root的区块中, 验证通过时返回nil
+func VerifyMerkleProof(root, txid string, proof *MerkleProof) error {
+	if proof == nil {
+		return errors.New("nil merkle proof")
+	}
+	expected, err := decodeHash(root)
+	if err != nil {
+		return err
+	}
+	h, err := decodeHash(txid)
+	if err != nil {
+		return err
+	}
+	branch, err := decodeHashes(proof.Branch)
+	if err != nil {
+		return err
+	}
+	index := proof.Index
+	for _, sibling := range branch {
+		if index&1 == 1 {
+			h = merkleHash(sibling, h)
+		} else {
+			h = merkleHash(h, sibling)
+		}
+		index >>= 1
+	}
+	if index != 0 {
+		return fmt.Errorf("merkle proof index %d out of range", proof.Index)
+	}
+	if h != expected {
+		return errors.New("merkle root mismatch")
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package gobbc
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+func TestMerkleRoot(t *testing.T) {
+	w := TW{T: t}
+	root, err := MerkleRoot(nil)
+	w.Nil(err).Equal("0000000000000000000000000000000000000000000000000000000000000000", root)
+
+	txids := []string{
+		"5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782",
+		"5dd6819721b3e6741acfdcf25e9ea3f06aae64b4a55d81051336dc180bf163e5",
+		"5dd6308dc2b4d93c0b9a7067c3133299fbf58db6c04bbfb0391b5c6b69703940",
+	}
+	root, err = MerkleRoot(txids[:1])
+	w.Nil(err).Equal(txids[0], root)
+
+	// 3个叶子: root = H(H(a,b), H(c,c))
+	leaves, err := decodeHashes(txids)
+	w.Nil(err)
+	hash := func(a, b [32]byte) [32]byte { return blake2b.Sum256(append(a[:], b[:]...)) }
+	expected := hash(hash(leaves[0], leaves[1]), hash(leaves[2], leaves[2]))
+	root, err = MerkleRoot(txids)
+	w.Nil(err).Equal(CopyReverseThenEncodeHex(expected[:]), root)
+
+	_, err = MerkleRoot([]string{"xx"})
+	w.True(err != nil)
+}
+
+func TestMerkleProof(t *testing.T) {
+	w := TW{T: t}
+	base := testBlock(t).Vtx[0]
+	for n := 1; n <= 7; n++ {
+		block := testBlock(t)
+		block.Vtx = nil
+		for i := 0; i < n; i++ {
+			tx := base
+			tx.Timestamp += uint32(i)
+			block.Vtx = append(block.Vtx, tx)
+		}
+		w.Nil(block.UpdateMerkleRoot())
+		txids, err := block.Txids()
+		w.Nil(err)
+		root, err := MerkleRoot(txids)
+		w.Nil(err).Equal(root, block.MerkleRoot())
+
+		for i, txid := range txids {
+			proof, err := BuildMerkleProof(block, txid)
+			w.Nil(err).Equal(uint32(i), proof.Index)
+			w.Nil(VerifyMerkleProof(root, txid, proof), fmt.Sprintf("n=%d i=%d", n, i))
+
+			other := txids[(i+1)%n]
+			if other != txid {
+				w.True(VerifyMerkleProof(root, other, proof) != nil, "wrong txid")
+			}
+			bad := *proof
+			bad.Index += 1 << uint(len(proof.Branch))
+			w.True(VerifyMerkleProof(root, txid, &bad) != nil, "index out of range")
+		}
+	}
+
+	_, err := BuildMerkleProof(testBlock(t), "5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782")
+	w.True(err != nil, "not in block")
+	w.True(VerifyMerkleProof("00", "00", nil) != nil)
+}
@@ -0,0 +1,119 @@
+package gobbc
+
+import (
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// PaymentTemplate 担保支付模版(core payment), 金额单位为最小单位:
+// - 支付: customer 向模版地址转入 Amount+Pledge
+// - 确认: HeightExec+HeightEnd 之前由 business 签名提取
+// - 退款: HeightExec+HeightEnd 之后由 customer 签名取回
+// |---33---|---33---|---4---|---8---|---8---|---4---|
+// |business|customer| exec  |amount |pledge |  end  |
+type PaymentTemplate struct {
+	Business   CDestination
+	Customer   CDestination
+	HeightExec uint32 //开始执行的高度
+	Amount     int64  //支付金额
+	Pledge     int64  //质押金额
+	HeightEnd  uint32 //执行期限(区块数)
+}
+
+// Total 需要转入模版地址的金额(最小单位)
+func (tpl *PaymentTemplate) Total() int64 { return tpl.Amount + tpl.Pledge }
+
+// CreateTemplateDataPayment 创建担保支付模版, 返回 模版地址, 模版数据hex
+func CreateTemplateDataPayment(tpl PaymentTemplate) (string, string, error) {
+	if tpl.Amount <= 0 || tpl.Pledge < 0 {
+		return "", "", fmt.Errorf("invalid payment amount %d, pledge %d", tpl.Amount, tpl.Pledge)
+	}
+	b := make([]byte, 0, 2*destinationLen+24)
+	b = append(b, tpl.Business.Bytes()...)
+	b = append(b, tpl.Customer.Bytes()...)
+	b = appendUint32(b, tpl.HeightExec)
+	b = appendUint64(b, uint64(tpl.Amount))
+	b = appendUint64(b, uint64(tpl.Pledge))
+	b = appendUint32(b, tpl.HeightEnd)
+	addr, tplHex := encodeTemplate(TemplateTypePayment, b)
+	return addr, tplHex, nil
+}
+
+// ParsePaymentTemplateHex 解析担保支付模版数据
+func ParsePaymentTemplateHex(tplHex string) (*PaymentTemplate, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypePayment {
+		return nil, fmt.Errorf("not a payment template: %s", typ)
+	}
+	r := dataReader{b: data}
+	tpl := PaymentTemplate{
+		Business:   r.destination("business"),
+		Customer:   r.destination("customer"),
+		HeightExec: r.uint32("height_exec"),
+		Amount:     int64(r.uint64("amount")),
+		Pledge:     int64(r.uint64("pledge")),
+		HeightEnd:  r.uint32("height_end"),
+	}
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// BuildPaymentPayTx customer 支付: 向模版地址转入 Amount+Pledge,
+// 调用方继续设置输入(customer的utxo)、时间戳、手续费后 Build, 使用customer私钥签名(与普通转账相同)
+func BuildPaymentPayTx(tplHex, anchor string) *TXBuilder {
+	b := NewTXBuilder().SetAnchor(anchor)
+	tpl, err := ParsePaymentTemplateHex(tplHex)
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	addr, err := TemplateAddress(tplHex)
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	return b.SetAddress(addr).setAmountRaw(tpl.Total())
+}
+
+// BuildPaymentConfirmTx business 确认: 从模版地址提取 Amount+Pledge-fee 到business,
+// 调用方继续设置输入(支付tx的输出)、时间戳后 Build,
+// 签名: rtx.SignWithPrivateKey(serializer, tplHex, businessPrivk) (business为多签地址时模版数据为 tplHex,multisigTpl)
+func BuildPaymentConfirmTx(tplHex string, fee float64, anchor string) *TXBuilder {
+	return buildPaymentSpendTx(tplHex, fee, anchor, func(tpl *PaymentTemplate) CDestination { return tpl.Business })
+}
+
+// BuildPaymentRefundTx customer 退款: 超过期限后从模版地址取回 Amount+Pledge-fee 到customer,
+// 调用方继续设置输入(支付tx的输出)、时间戳后 Build,
+// 签名: rtx.SignWithPrivateKey(serializer, tplHex, customerPrivk) (customer为多签地址时模版数据为 tplHex,multisigTpl)
+func BuildPaymentRefundTx(tplHex string, fee float64, anchor string) *TXBuilder {
+	return buildPaymentSpendTx(tplHex, fee, anchor, func(tpl *PaymentTemplate) CDestination { return tpl.Customer })
+}
+
+func buildPaymentSpendTx(tplHex string, fee float64, anchor string, to func(*PaymentTemplate) CDestination) *TXBuilder {
+	b := NewTXBuilder().SetAnchor(anchor)
+	if fee < 0 {
+		b.SetErr(fmt.Errorf("amount should be greater than 0"))
+		return b
+	}
+	tpl, err := ParsePaymentTemplateHex(tplHex)
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	b.SetAddress(to(tpl).String())
+	//模版金额为最小单位, 金额与手续费的和需要等于模版金额, 都不随链参数的精度变化
+	txFee := b.params.FromCoin(decimal.NewFromFloat(fee))
+	amount := tpl.Total() - txFee
+	if amount <= 0 {
+		b.SetErr(fmt.Errorf("fee exceeds payment total %d", tpl.Total()))
+		return b
+	}
+	b.rtx.TxFee = txFee
+	return b.setAmountRaw(amount)
+}
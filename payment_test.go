@@ -0,0 +1,64 @@
+package gobbc
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestPaymentTemplate(t *testing.T) {
+	w := TW{T: t}
+	business, err := MakeKeyPair()
+	w.Nil(err)
+	customer, err := MakeKeyPair()
+	w.Nil(err)
+	destB, err := NewCDestinationFromAddress(business.Addr)
+	w.Nil(err)
+	destC, err := NewCDestinationFromAddress(customer.Addr)
+	w.Nil(err)
+
+	tpl := PaymentTemplate{Business: destB, Customer: destC, HeightExec: 1000, Amount: 10 * Precision, Pledge: 2 * Precision, HeightEnd: 100}
+	addr, tplHex, err := CreateTemplateDataPayment(tpl)
+	w.Nil(err).True(strings.HasPrefix(addr, "2100"), addr) //模版类型8
+	w.Equal(2+33+33+4+8+8+4, len(tplHex)/2)
+	tplAddr, err := TemplateAddress(tplHex)
+	w.Nil(err).Equal(addr, tplAddr)
+	parsed, err := ParsePaymentTemplateHex(tplHex)
+	w.Nil(err).Equal(tpl, *parsed)
+	_, err = ParsePaymentTemplateHex(tplHex + "00")
+	w.True(err != nil)
+	_, _, err = CreateTemplateDataPayment(PaymentTemplate{Business: destB, Customer: destC})
+	w.True(err != nil, "amount required")
+
+	const input = "5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782"
+	pay, err := BuildPaymentPayTx(tplHex, BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).AddInput(input, 0).SetFee(0.01).Build()
+	w.Nil(err).Equal(tpl.Total(), pay.Amount)
+	w.Equal(addr, pay.ToTransaction(false).Address)
+
+	verify := func(rtx *RawTransaction, signer AddrKeyPair) {
+		tplData := mustHexDecode(t, tplHex)[2:]
+		w.True(bytes.HasPrefix(rtx.SignBytes, tplData))
+		hash, err := rtx.TxHash(BBCSerializer)
+		w.Nil(err)
+		pubk, err := ParsePublicKeyHex(signer.Pubk)
+		w.Nil(err)
+		w.True(ed25519.Verify(pubk, hash[:], rtx.SignBytes[len(tplData):]))
+	}
+
+	confirm, err := BuildPaymentConfirmTx(tplHex, 0.01, BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474815).AddInput(input, 0).Build()
+	w.Nil(err).Equal(tpl.Total()-10000, confirm.Amount).Equal(business.Addr, confirm.ToTransaction(false).Address)
+	w.Nil(confirm.SignWithPrivateKey(BBCSerializer, tplHex, business.Privk))
+	verify(confirm, business)
+
+	refund, err := BuildPaymentRefundTx(tplHex, 0.01, BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474915).AddInput(input, 0).Build()
+	w.Nil(err).Equal(customer.Addr, refund.ToTransaction(false).Address)
+	w.Nil(refund.SignWithPrivateKey(BBCSerializer, tplHex, customer.Privk))
+	verify(refund, customer)
+
+	_, err = BuildPaymentRefundTx(tplHex, 12, BBCMainnet.GenesisAnchor).AddInput(input, 0).Build()
+	w.True(err != nil, "fee exceeds total")
+}
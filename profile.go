@@ -0,0 +1,260 @@
+package gobbc
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// fork profile 字段, 参考core CProfile (map的key为int, 4字节)
+const (
+	profileVersion     uint32 = 0
+	profileName        uint32 = 1
+	profileSymbol      uint32 = 2
+	profileFlag        uint32 = 3
+	profileAmount      uint32 = 4
+	profileMintReward  uint32 = 5
+	profileMinTxFee    uint32 = 6
+	profileHalveCycle  uint32 = 7
+	profileOwner       uint32 = 8
+	profileParent      uint32 = 9
+	profileJointHeight uint32 = 10
+)
+
+// fork profile flag
+const (
+	profileFlagIsolated uint8 = 1
+	profileFlagPrivate  uint8 = 2
+	profileFlagEnclosed uint8 = 4
+)
+
+// forkBlockSpacing 出块间隔(秒), origin块时间戳为前一区块时间戳+出块间隔
+const forkBlockSpacing = 60
+
+// forkProfile 分支配置, 参考core CProfile, 金额单位均为最小单位.
+// 编码与origin块在 TestMakeOriginCoreVector 使用core makeorigin 的结果验证通过之前不导出
+type forkProfile struct {
+	Version     int32
+	Name        string
+	Symbol      string
+	Isolated    bool
+	Private     bool
+	Enclosed    bool
+	Amount      int64 //初始发行量
+	MintReward  int64 //出块奖励
+	MinTxFee    int64
+	HalveCycle  uint32 //出块奖励减半周期(区块数), 0表示不减半
+	Owner       CDestination
+	Parent      string //父分支id(hex), 为空表示无父分支
+	JointHeight int32  //在父分支上的分叉高度
+}
+
+// Encode 按照core CProfile::Save 编码: map<int, vector<uint8>>, 用于origin块的vchProof
+func (p *forkProfile) Encode() ([]byte, error) {
+	type item struct {
+		key   uint32
+		value []byte
+	}
+	var flag uint8
+	if p.Isolated {
+		flag |= profileFlagIsolated
+	}
+	if p.Private {
+		flag |= profileFlagPrivate
+	}
+	if p.Enclosed {
+		flag |= profileFlagEnclosed
+	}
+	items := []item{
+		{profileVersion, appendUint32(nil, uint32(p.Version))},
+		{profileName, appendVarBytes(nil, []byte(p.Name))},
+		{profileSymbol, appendVarBytes(nil, []byte(p.Symbol))},
+		{profileFlag, []byte{flag}},
+		{profileAmount, appendUint64(nil, uint64(p.Amount))},
+		{profileMintReward, appendUint64(nil, uint64(p.MintReward))},
+		{profileMinTxFee, appendUint64(nil, uint64(p.MinTxFee))},
+		{profileHalveCycle, appendUint32(nil, p.HalveCycle)},
+	}
+	if p.Owner != (CDestination{}) {
+		items = append(items, item{profileOwner, p.Owner.Bytes()})
+	}
+	if p.Parent != "" {
+		parent, err := decodeHash(p.Parent)
+		if err != nil {
+			return nil, fmt.Errorf("invalid parent fork id, %v", err)
+		}
+		items = append(items,
+			item{profileParent, parent[:]},
+			item{profileJointHeight, appendUint32(nil, uint32(p.JointHeight))})
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].key < items[j].key })
+
+	b := appendUint64(nil, uint64(len(items)))
+	for _, it := range items {
+		b = appendUint32(b, it.key)
+		b = appendVarBytes(b, it.value)
+	}
+	return b, nil
+}
+
+// parseForkProfile 解析profile数据(origin块的vchProof), 未知的字段忽略
+func parseForkProfile(b []byte) (*forkProfile, error) {
+	r := dataReader{b: b}
+	n := r.uint64("profile.size")
+	var p forkProfile
+	for i := uint64(0); i < n && r.err == nil; i++ {
+		key := r.uint32("profile.key")
+		value := dataReader{b: r.varBytes("profile.value")}
+		if r.err != nil {
+			break
+		}
+		var field string
+		switch key {
+		case profileVersion:
+			field, p.Version = "version", int32(value.uint32("version"))
+		case profileName:
+			field, p.Name = "name", string(value.varBytes("name"))
+		case profileSymbol:
+			field, p.Symbol = "symbol", string(value.varBytes("symbol"))
+		case profileFlag:
+			field = "flag"
+			flag := value.uint8(field)
+			p.Isolated = flag&profileFlagIsolated != 0
+			p.Private = flag&profileFlagPrivate != 0
+			p.Enclosed = flag&profileFlagEnclosed != 0
+		case profileAmount:
+			field, p.Amount = "amount", int64(value.uint64("amount"))
+		case profileMintReward:
+			field, p.MintReward = "mintreward", int64(value.uint64("mintreward"))
+		case profileMinTxFee:
+			field, p.MinTxFee = "mintxfee", int64(value.uint64("mintxfee"))
+		case profileHalveCycle:
+			field, p.HalveCycle = "halvecycle", value.uint32("halvecycle")
+		case profileOwner:
+			field, p.Owner = "owner", value.destination("owner")
+		case profileParent:
+			field = "parent"
+			if parent := value.hash(field); parent != ([32]byte{}) {
+				p.Parent = CopyReverseThenEncodeHex(parent[:])
+			}
+		case profileJointHeight:
+			field, p.JointHeight = "jointheight", int32(value.uint32("jointheight"))
+		default:
+			continue
+		}
+		if err := value.close(); err != nil {
+			return nil, fmt.Errorf("invalid profile %s, %v", field, err)
+		}
+	}
+	if err := r.close(); err != nil {
+		return nil, fmt.Errorf("invalid profile, %v", err)
+	}
+	return &p, nil
+}
+
+// newOriginBlock 创建分支origin块(参考core rpc makeorigin), 未签名, 签名使用 Block.Sign(owner私钥),
+// prevBlockHash: 父分支上分叉位置的区块hash(高度须与profile.JointHeight一致), prevBlockTime: 该区块的时间戳,
+// 签名后的区块hash即为分支id
+func newOriginBlock(serializer Serializer, profile *forkProfile, prevBlockHash string, prevBlockTime uint32) (*Block, error) {
+	if profile.Name == "" || profile.Symbol == "" {
+		return nil, errors.New("fork name and symbol required")
+	}
+	if profile.Owner.Prefix != PrefixPubk {
+		return nil, errors.New("owner should be pubkey address")
+	}
+	if profile.Amount < 0 || profile.MintReward < 0 || profile.MinTxFee < 0 {
+		return nil, errors.New("amount, mint reward and min tx fee should not be negative")
+	}
+	block := NewBlock(serializer)
+	if err := block.SetPrevHash(prevBlockHash); err != nil {
+		return nil, err
+	}
+	if prevHeight := block.Height() - 1; profile.Parent != "" && int32(prevHeight) != profile.JointHeight {
+		return nil, fmt.Errorf("joint height %d mismatch prev block height %d", profile.JointHeight, prevHeight)
+	}
+	proof, err := profile.Encode()
+	if err != nil {
+		return nil, err
+	}
+	block.Version = 1
+	block.Type = BlockTypeOrigin
+	block.Timestamp = prevBlockTime + forkBlockSpacing
+	block.Proof = proof
+	block.TxMint = RawTransaction{
+		Version:      1,
+		Typ:          uint16(TxTypeGenesis),
+		Timestamp:    block.Timestamp,
+		Input:        []byte{},
+		Prefix:       profile.Owner.Prefix,
+		AddressBytes: profile.Owner.Data,
+		Amount:       profile.Amount,
+		SizeOut:      uint64(len(profile.Name)),
+		VchData:      []byte(profile.Name),
+		SignBytes:    []byte{},
+	}
+	return block, nil
+}
+
+// profile 解析origin块中的分支配置
+func (block *Block) profile() (*forkProfile, error) {
+	if block.Type != BlockTypeOrigin {
+		return nil, fmt.Errorf("not an origin block: %s", block.Type)
+	}
+	return parseForkProfile(block.Proof)
+}
+
+// Sign 使用私钥对区块hash签名(出块者/分支owner)
+func (block *Block) Sign(privkHex string) error {
+	privk, err := ParsePrivkHex(privkHex)
+	if err != nil {
+		return fmt.Errorf("unable to parse private key, %v", err)
+	}
+	hash, err := block.HashBytes()
+	if err != nil {
+		return err
+	}
+	block.Sig = ed25519.Sign(privk, hash[:])
+	return nil
+}
+
+// VerifySignature 验证区块签名, pubkHex: 公钥hex
+func (block *Block) VerifySignature(pubkHex string) (bool, error) {
+	pubk, err := ParsePublicKeyHex(pubkHex)
+	if err != nil {
+		return false, err
+	}
+	hash, err := block.HashBytes()
+	if err != nil {
+		return false, err
+	}
+	return ed25519.Verify(pubk, hash[:], block.Sig), nil
+}
+
+// buildCreateForkTx 创建分支交易: 将质押的币转入分支模版地址, vchData 为签名后的origin块,
+// 调用方需要继续设置输入、时间戳、手续费(vchData较大, 建议使用 SetChainParams + SetMinFee)后 Build,
+// 签名时需要使用from地址对应的私钥(from为模版地址时还需要其模版数据).
+// 分支id取决于profile的编码, 与 forkProfile 一样在验证通过之前不导出
+func buildCreateForkTx(origin *Block, redeem CDestination, pledge float64, anchor string) *TXBuilder {
+	b := NewTXBuilder().SetAnchor(anchor)
+	if origin.Type != BlockTypeOrigin || len(origin.Sig) == 0 {
+		b.SetErr(errors.New("signed origin block required"))
+		return b
+	}
+	forkID, err := origin.Hash()
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	data, err := origin.EncodeBytes()
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	addr, _, err := CreateTemplateDataFork(redeem, forkID)
+	if err != nil {
+		b.SetErr(err)
+		return b
+	}
+	return b.SetAddress(addr).SetAmount(pledge).SetRawData(data)
+}
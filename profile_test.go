@@ -0,0 +1,189 @@
+package gobbc
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestForkProfile(t *testing.T) {
+	w := TW{T: t}
+	owner, err := NewCDestinationFromAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm")
+	w.Nil(err)
+
+	p := forkProfile{
+		Version:     1,
+		Name:        "test-fork",
+		Symbol:      "TF",
+		Isolated:    true,
+		Amount:      100000000 * Precision,
+		MintReward:  15 * Precision,
+		MinTxFee:    10000,
+		HalveCycle:  525600,
+		Owner:       owner,
+		Parent:      BBCMainnet.GenesisAnchor,
+		JointHeight: 100,
+	}
+	b, err := p.Encode()
+	w.Nil(err)
+	// map size + version(key int32, len, int32)
+	w.Equal("0b00000000000000"+"00000000"+"0400000000000000"+"01000000", hex.EncodeToString(b[:24]))
+	w.True(bytes.Contains(b, appendVarBytes(appendUint32(nil, profileName), appendVarBytes(nil, []byte("test-fork")))))
+
+	parsed, err := parseForkProfile(b)
+	w.Nil(err).Equal(p, *parsed)
+
+	// 没有owner, parent
+	p2 := forkProfile{Name: "x", Symbol: "X", Private: true, Enclosed: true}
+	b, err = p2.Encode()
+	w.Nil(err).Equal(uint8(8), b[0])
+	parsed, err = parseForkProfile(b)
+	w.Nil(err).Equal(p2, *parsed)
+
+	_, err = parseForkProfile(b[:len(b)-1])
+	w.True(err != nil, "truncated")
+	_, err = parseForkProfile(append(b, 0))
+	w.True(err != nil, "trailing")
+	p2.Parent = "xx"
+	_, err = p2.Encode()
+	w.True(err != nil, "invalid parent")
+}
+
+func TestForkTemplate(t *testing.T) {
+	w := TW{T: t}
+	redeem, err := NewCDestinationFromAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm")
+	w.Nil(err)
+	addr, tplHex, err := CreateTemplateDataFork(redeem, BBCMainnet.GenesisAnchor)
+	w.Nil(err).True(strings.HasPrefix(addr, "20c0"), addr) //模版类型3
+	w.Equal(2+33+32, len(tplHex)/2)
+	tplAddr, err := TemplateAddress(tplHex)
+	w.Nil(err).Equal(addr, tplAddr)
+
+	tpl, err := ParseForkTemplateHex(tplHex)
+	w.Nil(err).Equal(redeem, tpl.Redeem).Equal(BBCMainnet.GenesisAnchor, tpl.ForkID)
+	_, err = ParseForkTemplateHex(tplHex + "00")
+	w.True(err != nil)
+	_, _, err = CreateTemplateDataFork(redeem, "00")
+	w.True(err != nil)
+}
+
+func TestCreateFork(t *testing.T) {
+	w := TW{T: t}
+	owner, err := MakeKeyPair()
+	w.Nil(err)
+	ownerDest, err := NewCDestinationFromAddress(owner.Addr)
+	w.Nil(err)
+	profile := &forkProfile{
+		Version:     1,
+		Name:        "test-fork",
+		Symbol:      "TF",
+		Amount:      100000000 * Precision,
+		MintReward:  15 * Precision,
+		MinTxFee:    10000,
+		Owner:       ownerDest,
+		Parent:      BBCMainnet.GenesisAnchor,
+		JointHeight: 100,
+	}
+	prev := "00000064f3a5e8b2a6f0ad5e0d4e1b2c3d4e5f60718293a4b5c6d7e8f9a0b1c2"
+	origin, err := newOriginBlock(BBCMainnet, profile, prev, 1590474715)
+	w.Nil(err).Equal(uint32(101), origin.Height()).Equal(uint32(1590474775), origin.Timestamp)
+	w.Equal(TxTypeGenesis, origin.TxMint.TxType()).Equal(profile.Amount, origin.TxMint.Amount)
+
+	_, err = buildCreateForkTx(origin, ownerDest, 100000, BBCMainnet.GenesisAnchor).Build()
+	w.True(err != nil, "origin not signed")
+
+	w.Nil(origin.Sign(owner.Privk))
+	ok, err := origin.VerifySignature(owner.Pubk)
+	w.Nil(err).True(ok)
+	forkID, err := origin.Hash()
+	w.Nil(err).True(strings.HasPrefix(forkID, "00000065"), forkID)
+
+	hexed, err := origin.Encode()
+	w.Nil(err)
+	decoded, err := DecodeBlock(BBCStrictSerializer, hexed)
+	w.Nil(err)
+	decodedID, err := decoded.Hash()
+	w.Nil(err).Equal(forkID, decodedID)
+	decodedProfile, err := decoded.profile()
+	w.Nil(err).Equal(*profile, *decodedProfile)
+
+	rtx, err := buildCreateForkTx(origin, ownerDest, 100000, BBCMainnet.GenesisAnchor).
+		SetChainParams(BBCMainnet).
+		SetTimestamp(1590474800).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1).
+		SetMinFee().
+		Build()
+	w.Nil(err)
+	w.Equal(BBCMainnet.EstimateFee(rtx), rtx.TxFee)
+	forkAddr, _, err := CreateTemplateDataFork(ownerDest, forkID)
+	w.Nil(err)
+	tx := rtx.ToTransaction(false)
+	w.Equal(forkAddr, tx.Address)
+	inTx, err := DecodeBlock(BBCSerializer, hex.EncodeToString(rtx.VchData))
+	w.Nil(err)
+	inTxID, err := inTx.Hash()
+	w.Nil(err).Equal(forkID, inTxID)
+
+	profile.JointHeight = 99
+	_, err = newOriginBlock(BBCMainnet, profile, prev, 1590474715)
+	w.True(err != nil, "joint height mismatch")
+	profile.JointHeight = 100
+	profile.Owner = CDestination{Prefix: PrefixTemplate}
+	_, err = newOriginBlock(BBCMainnet, profile, prev, 1590474715)
+	w.True(err != nil, "owner should be pubkey")
+}
+
+// TestMakeOriginCoreVector 与core rpc makeorigin 的结果逐字节比较
+func TestMakeOriginCoreVector(t *testing.T) {
+	var v struct {
+		Prev         string `json:"prev"`
+		PrevTime     uint32 `json:"prev_time"`
+		OwnerPrivkey string `json:"owner_privkey"`
+		Name         string `json:"name"`
+		Symbol       string `json:"symbol"`
+		Amount       int64  `json:"amount"`
+		Reward       int64  `json:"reward"`
+		HalveCycle   uint32 `json:"halvecycle"`
+		Isolated     bool   `json:"isolated"`
+		Private      bool   `json:"private"`
+		Enclosed     bool   `json:"enclosed"`
+		Parent       string `json:"parent"`
+		JointHeight  int32  `json:"joint_height"`
+		ProfileHex   string `json:"profile_hex"`
+		Hex          string `json:"hex"`
+		Hash         string `json:"hash"`
+	}
+	loadCoreVector(t, "makeorigin", &v)
+	w := TW{T: t}
+	owner, err := ParsePrivkHex(v.OwnerPrivkey)
+	w.Nil(err)
+	ownerDest := CDestination{Prefix: PrefixPubk}
+	copy(ownerDest.Data[:], owner.Public().(ed25519.PublicKey))
+	profile := &forkProfile{
+		Version:     1,
+		Name:        v.Name,
+		Symbol:      v.Symbol,
+		Isolated:    v.Isolated,
+		Private:     v.Private,
+		Enclosed:    v.Enclosed,
+		Amount:      v.Amount * Precision,
+		MintReward:  v.Reward * Precision,
+		MinTxFee:    BBCMainnet.Fee.MinTxFee,
+		HalveCycle:  v.HalveCycle,
+		Owner:       ownerDest,
+		Parent:      v.Parent,
+		JointHeight: v.JointHeight,
+	}
+	b, err := profile.Encode()
+	w.Nil(err).Equal(v.ProfileHex, hex.EncodeToString(b))
+
+	origin, err := newOriginBlock(BBCMainnet, profile, v.Prev, v.PrevTime)
+	w.Nil(err)
+	w.Nil(origin.Sign(v.OwnerPrivkey))
+	hexed, err := origin.Encode()
+	w.Nil(err).Equal(v.Hex, hexed)
+	hash, err := origin.Hash()
+	w.Nil(err).Equal(v.Hash, hash)
+}
@@ -1,13 +1,20 @@
 package qa
 
 import (
+	"encoding/hex"
 	"fmt"
 
 	"github.com/dabankio/bbrpc"
 	"github.com/dabankio/gobbc"
 )
 
-var changeTxVersionTo = "ffff" //某些链上要改tx版本
+// qaChain 集成测试节点的链参数, 该链上tx版本为ffff
+var qaChain = func() *gobbc.ChainParams {
+	p := *gobbc.BBCTestnet
+	p.Name = "qa"
+	p.DefaultTxVersion = 0xffff
+	return &p
+}()
 
 func signAndSendtransaction(cmd bbrpc.CmdSendfrom, privateKeys []string, client *bbrpc.Client, tplAddress ...string) (*string, error) {
 	txP, err := client.Createtransaction(bbrpc.CmdCreatetransaction{
@@ -19,16 +26,16 @@ func signAndSendtransaction(cmd bbrpc.CmdSendfrom, privateKeys []string, client
 		return nil, err
 	}
 
-	if changeTxVersionTo != "" {
-		_tx := changeTxVersionTo + (*txP)[4:]
+	if v := qaChain.DefaultTxVersion; v != 0 {
+		_tx := hex.EncodeToString([]byte{byte(v), byte(v >> 8)}) + (*txP)[4:]
 		txP = &_tx
 	}
-	rawTX, err := gobbc.DecodeRawTransaction(gobbc.BBCSerializer, *txP, false)
+	rawTX, err := gobbc.DecodeRawTransaction(qaChain, *txP, false)
 	if err != nil {
 		return nil, err
 	}
 	if len(tplAddress) == 0 {
-		err = rawTX.SignWithPrivateKey(gobbc.BBCSerializer, "", privateKeys[0])
+		err = rawTX.SignWithPrivateKey(qaChain, "", privateKeys[0])
 		if err != nil {
 			return nil, err
 		}
@@ -46,13 +53,13 @@ func signAndSendtransaction(cmd bbrpc.CmdSendfrom, privateKeys []string, client
 
 		}
 		for _, privateKey := range privateKeys {
-			err = rawTX.SignWithPrivateKey(gobbc.BBCSerializer, templateData, privateKey)
+			err = rawTX.SignWithPrivateKey(qaChain, templateData, privateKey)
 			if err != nil {
 				return nil, err
 			}
 		}
 	}
-	signedTx, err := rawTX.Encode(gobbc.BBCSerializer, true)
+	signedTx, err := rawTX.Encode(qaChain, true)
 	if err != nil {
 		return nil, err
 	}
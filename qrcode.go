@@ -0,0 +1,549 @@
+package gobbc
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// QRLevel 二维码纠错等级
+type QRLevel int
+
+// 纠错等级, 约可恢复 7%, 15%, 25%, 30% 的数据
+const (
+	QRLevelL QRLevel = iota
+	QRLevelM
+	QRLevelQ
+	QRLevelH
+)
+
+// ErrQRDataTooLong 数据超过二维码(version 40)的容量
+var ErrQRDataTooLong = errors.New("data too long for qr code")
+
+// qrAlphanumeric 二维码 alphanumeric 模式的字符集, 顺序即编码值
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// 每块的纠错码字数和块数, 下标为 [level][version], 参考 ISO/IEC 18004 Table 9
+var (
+	qrECCPerBlock = [4][41]int8{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	qrNumBlocks = [4][41]int8{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+	qrFormatLevelBits = [4]int{1, 0, 3, 2}
+)
+
+// QRCode 二维码, 使用 EncodeQR 生成
+type QRCode struct {
+	Version int
+	Level   QRLevel
+	Size    int //每边的模块数
+	Mask    int
+
+	modules    [][]bool //[y][x], true 为深色
+	isFunction [][]bool
+}
+
+// EncodeQR 生成二维码, 文本只包含大写字母、数字和 " $%*+-./:" 时使用 alphanumeric 模式, 否则使用 byte 模式;
+// 自动选择能容纳数据的最小版本
+func EncodeQR(text string, level QRLevel) (*QRCode, error) {
+	if level < QRLevelL || level > QRLevelH {
+		return nil, errors.New("invalid qr level")
+	}
+	alnum := qrIsAlphanumeric(text)
+	version, dataBits := 0, 0
+	for v := 1; v <= 40; v++ {
+		dataBits = qrSegmentBits(text, alnum, v)
+		if dataBits <= qrNumDataCodewords(v, level)*8 {
+			version = v
+			break
+		}
+	}
+	if version == 0 {
+		return nil, ErrQRDataTooLong
+	}
+
+	var bb qrBitBuffer
+	if alnum {
+		bb.append(0x2, 4)
+		bb.append(len(text), qrCharCountBits(true, version))
+		for i := 0; i+1 < len(text); i += 2 {
+			bb.append(strings.IndexByte(qrAlphanumeric, text[i])*45+strings.IndexByte(qrAlphanumeric, text[i+1]), 11)
+		}
+		if len(text)%2 == 1 {
+			bb.append(strings.IndexByte(qrAlphanumeric, text[len(text)-1]), 6)
+		}
+	} else {
+		bb.append(0x4, 4)
+		bb.append(len(text), qrCharCountBits(false, version))
+		for i := 0; i < len(text); i++ {
+			bb.append(int(text[i]), 8)
+		}
+	}
+	capacity := qrNumDataCodewords(version, level) * 8
+	bb.append(0, qrMin(4, capacity-len(bb)))
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < capacity; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	data := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			data[i>>3] |= 1 << uint(7-i&7)
+		}
+	}
+	return newQRCode(version, level, qrAddECCAndInterleave(data, version, level)), nil
+}
+
+func newQRCode(version int, level QRLevel, codewords []byte) *QRCode {
+	q := QRCode{Version: version, Level: level, Size: version*4 + 17}
+	q.modules = make([][]bool, q.Size)
+	q.isFunction = make([][]bool, q.Size)
+	for i := range q.modules {
+		q.modules[i] = make([]bool, q.Size)
+		q.isFunction[i] = make([]bool, q.Size)
+	}
+	q.drawFunctionPatterns()
+	q.drawCodewords(codewords)
+
+	minPenalty := -1
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormatBits(mask)
+		if p := q.penalty(); minPenalty < 0 || p < minPenalty {
+			q.Mask, minPenalty = mask, p
+		}
+		q.applyMask(mask) //异或两次即还原
+	}
+	q.applyMask(q.Mask)
+	q.drawFormatBits(q.Mask)
+	return &q
+}
+
+// Module (x, y) 是否为深色, 超出范围为浅色
+func (q *QRCode) Module(x, y int) bool {
+	return x >= 0 && x < q.Size && y >= 0 && y < q.Size && q.modules[y][x]
+}
+
+// Image 生成图片, scale 为每个模块的像素数, border 为四周空白的模块数(标准为4)
+func (q *QRCode) Image(scale, border int) image.Image {
+	if scale < 1 {
+		scale = 1
+	}
+	if border < 0 {
+		border = 0
+	}
+	n := (q.Size + border*2) * scale
+	img := image.NewPaletted(image.Rect(0, 0, n, n), color.Palette{color.White, color.Black})
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.Module(x/scale-border, y/scale-border) {
+				img.SetColorIndex(x, y, 1)
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG 输出png图片, 参考 Image
+func (q *QRCode) WritePNG(w io.Writer, scale, border int) error {
+	return png.Encode(w, q.Image(scale, border))
+}
+
+// Text 使用字符块绘制, 用于终端显示(深色背景终端可能需要反色)
+func (q *QRCode) Text(border int) string {
+	var sb strings.Builder
+	for y := -border; y < q.Size+border; y++ {
+		for x := -border; x < q.Size+border; x++ {
+			if q.Module(x, y) {
+				sb.WriteString("██")
+			} else {
+				sb.WriteString("  ")
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+func (q *QRCode) setFunction(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.isFunction[y][x] = true
+}
+
+func (q *QRCode) drawFunctionPatterns() {
+	for i := 0; i < q.Size; i++ { //timing
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(q.Size-4, 3)
+	q.drawFinder(3, q.Size-4)
+
+	pos := qrAlignmentPositions(q.Version)
+	n := len(pos)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			if (i == 0 && j == 0) || (i == 0 && j == n-1) || (i == n-1 && j == 0) { //与finder重叠
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.setFunction(pos[i]+dx, pos[j]+dy, qrMax(qrAbs(dx), qrAbs(dy)) != 1)
+				}
+			}
+		}
+	}
+	q.drawFormatBits(0) //占位, 选择mask后重新绘制
+	q.drawVersion()
+}
+
+// drawFinder 以(x, y)为中心的定位图形及分隔符
+func (q *QRCode) drawFinder(x, y int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			xx, yy := x+dx, y+dy
+			if xx >= 0 && xx < q.Size && yy >= 0 && yy < q.Size {
+				dist := qrMax(qrAbs(dx), qrAbs(dy))
+				q.setFunction(xx, yy, dist != 2 && dist != 4)
+			}
+		}
+	}
+}
+
+func (q *QRCode) drawFormatBits(mask int) {
+	data := qrFormatLevelBits[q.Level]<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return (bits>>uint(i))&1 != 0 }
+
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.Size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.Size-15+i, bit(i))
+	}
+	q.setFunction(8, q.Size-8, true) //dark module
+}
+
+func (q *QRCode) drawVersion() {
+	if q.Version < 7 {
+		return
+	}
+	rem := q.Version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits := q.Version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := (bits>>uint(i))&1 != 0
+		a, b := q.Size-11+i%3, i/3
+		q.setFunction(a, b, dark)
+		q.setFunction(b, a, dark)
+	}
+}
+
+// drawCodewords 从右下角开始, 每两列为一组上下交替填充数据
+func (q *QRCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if !q.isFunction[y][x] && i < len(data)*8 {
+					q.modules[y][x] = (data[i>>3]>>uint(7-i&7))&1 != 0
+					i++
+				}
+			}
+		}
+	}
+}
+
+func (q *QRCode) applyMask(mask int) {
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.isFunction[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty mask 的评分(越小越好), 参考 ISO/IEC 18004 7.8.3
+func (q *QRCode) penalty() int {
+	const n1, n2, n3, n4 = 3, 3, 40, 10
+	get := func(x, y int, column bool) bool {
+		if column {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := [][]bool{
+		{true, false, true, true, true, false, true, false, false, false, false},
+		{false, false, false, false, true, false, true, true, true, false, true},
+	}
+	result := 0
+	for _, column := range []bool{false, true} {
+		for y := 0; y < q.Size; y++ {
+			run := 1
+			for x := 1; x <= q.Size; x++ {
+				if x < q.Size && get(x, y, column) == get(x-1, y, column) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					result += n1 + run - 5
+				}
+				run = 1
+			}
+			for x := 0; x+11 <= q.Size; x++ {
+				for _, p := range finderLike {
+					match := true
+					for k := range p {
+						if get(x+k, y, column) != p[k] {
+							match = false
+							break
+						}
+					}
+					if match {
+						result += n3
+					}
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < q.Size; y++ {
+		for x := 0; x < q.Size; x++ {
+			c := q.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < q.Size && y+1 < q.Size && c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				result += n2
+			}
+		}
+	}
+	total := q.Size * q.Size
+	k := (qrAbs(dark*20-total*10)+total-1)/total - 1
+	return result + k*n4
+}
+
+func qrIsAlphanumeric(text string) bool {
+	for i := 0; i < len(text); i++ {
+		if strings.IndexByte(qrAlphanumeric, text[i]) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func qrCharCountBits(alnum bool, version int) int {
+	i := 0
+	if version >= 27 {
+		i = 2
+	} else if version >= 10 {
+		i = 1
+	}
+	if alnum {
+		return [3]int{9, 11, 13}[i]
+	}
+	return [3]int{8, 16, 16}[i]
+}
+
+// qrSegmentBits 数据段(模式+长度+数据)的比特数, 长度超过计数字段时返回很大的值
+func qrSegmentBits(text string, alnum bool, version int) int {
+	n := len(text)
+	if n >= 1<<uint(qrCharCountBits(alnum, version)) {
+		return 1 << 30
+	}
+	bits := n * 8
+	if alnum {
+		bits = n/2*11 + n%2*6
+	}
+	return 4 + qrCharCountBits(alnum, version) + bits
+}
+
+// qrNumRawDataModules 除功能图形外可用于数据和纠错码的模块数
+func qrNumRawDataModules(version int) int {
+	result := (16*version+128)*version + 64
+	if version >= 2 {
+		numAlign := version/7 + 2
+		result -= (25*numAlign-10)*numAlign - 55
+		if version >= 7 {
+			result -= 36
+		}
+	}
+	return result
+}
+
+func qrNumDataCodewords(version int, level QRLevel) int {
+	return qrNumRawDataModules(version)/8 - int(qrECCPerBlock[level][version])*int(qrNumBlocks[level][version])
+}
+
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	numAlign := version/7 + 2
+	step := 26
+	if version != 32 {
+		step = (version*4 + numAlign*2 + 1) / (numAlign*2 - 2) * 2
+	}
+	result := make([]int, numAlign)
+	result[0] = 6
+	for i, pos := numAlign-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		result[i] = pos
+	}
+	return result
+}
+
+// qrAddECCAndInterleave 分块计算纠错码并交错排列
+func qrAddECCAndInterleave(data []byte, version int, level QRLevel) []byte {
+	numBlocks := int(qrNumBlocks[level][version])
+	blockECCLen := int(qrECCPerBlock[level][version])
+	rawCodewords := qrNumRawDataModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortBlockLen := rawCodewords / numBlocks
+
+	divisor := qrReedSolomonDivisor(blockECCLen)
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortBlockLen - blockECCLen
+		if i >= numShortBlocks {
+			n++
+		}
+		dat := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := qrReedSolomonRemainder(dat, divisor)
+		if i < numShortBlocks {
+			dat = append(dat, 0) //占位, 交错时跳过
+		}
+		blocks[i] = append(dat, ecc...)
+	}
+	result := make([]byte, 0, rawCodewords)
+	for i := range blocks[0] {
+		for j, block := range blocks {
+			if i != shortBlockLen-blockECCLen || j >= numShortBlocks {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+func qrReedSolomonDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = qrGFMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = qrGFMultiply(root, 0x02)
+	}
+	return result
+}
+
+func qrReedSolomonRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i, d := range divisor {
+			result[i] ^= qrGFMultiply(d, factor)
+		}
+	}
+	return result
+}
+
+// qrGFMultiply GF(2^8) 乘法, 模 x^8 + x^4 + x^3 + x^2 + 1
+func qrGFMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>uint(i))&1) * int(x)
+	}
+	return byte(z)
+}
+
+type qrBitBuffer []bool
+
+func (bb *qrBitBuffer) append(val, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*bb = append(*bb, (val>>uint(i))&1 != 0)
+	}
+}
+
+func qrMin(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func qrMax(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func qrAbs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
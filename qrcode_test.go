@@ -0,0 +1,129 @@
+package gobbc
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// readQRCodewords 按 drawCodewords 的顺序读回去除mask后的码字
+func readQRCodewords(q *QRCode) []byte {
+	q.applyMask(q.Mask)
+	defer q.applyMask(q.Mask)
+	var data []byte
+	i := 0
+	for right := q.Size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.Size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.Size - 1 - vert
+				}
+				if q.isFunction[y][x] {
+					continue
+				}
+				if i%8 == 0 {
+					data = append(data, 0)
+				}
+				if q.modules[y][x] {
+					data[i/8] |= 1 << uint(7-i%8)
+				}
+				i++
+			}
+		}
+	}
+	return data[:qrNumRawDataModules(q.Version)/8]
+}
+
+func TestEncodeQR(t *testing.T) {
+	w := TW{T: t}
+
+	// https://www.thonky.com/qr-code-tutorial 中 HELLO WORLD(1-Q) 的数据和纠错码字
+	q, err := EncodeQR("HELLO WORLD", QRLevelQ)
+	w.Nil(err).Equal(1, q.Version).Equal(21, q.Size)
+	expected := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236,
+		168, 72, 22, 82, 217, 54, 156, 0, 46, 15, 180, 122, 16}
+	w.Equal(expected, readQRCodewords(q))
+
+	// 格式信息: Q + mask
+	formatQ := []int{0x355F, 0x3068, 0x3F31, 0x3A06, 0x24B4, 0x2183, 0x2EDA, 0x2BED}
+	format := 0
+	for i := 0; i <= 5; i++ {
+		if q.Module(8, i) {
+			format |= 1 << uint(i)
+		}
+	}
+	for i, p := range [][2]int{{8, 7}, {8, 8}, {7, 8}} {
+		if q.Module(p[0], p[1]) {
+			format |= 1 << uint(6+i)
+		}
+	}
+	for i := 9; i < 15; i++ {
+		if q.Module(14-i, 8) {
+			format |= 1 << uint(i)
+		}
+	}
+	w.Equal(formatQ[q.Mask], format)
+
+	// finder pattern
+	for _, c := range [][2]int{{0, 0}, {q.Size - 7, 0}, {0, q.Size - 7}} {
+		w.True(q.Module(c[0], c[1]) && q.Module(c[0]+6, c[1]+6) && !q.Module(c[0]+1, c[1]+1) && q.Module(c[0]+3, c[1]+3))
+	}
+
+	// version 7 以上的版本信息
+	q, err = EncodeQR(strings.Repeat("A", 179), QRLevelM)
+	w.Nil(err).Equal(8, q.Version)
+	q, err = EncodeQR(strings.Repeat("A", 178), QRLevelM) //7-M alphanumeric 容量
+	w.Nil(err).Equal(7, q.Version)
+	version := 0
+	for i := 0; i < 18; i++ {
+		if q.Module(q.Size-11+i%3, i/3) {
+			version |= 1 << uint(i)
+		}
+	}
+	w.Equal(0x07C94, version)
+
+	// byte 模式, 多块
+	text := strings.Repeat("bbc tx ", 100)
+	q, err = EncodeQR(text, QRLevelH)
+	w.Nil(err)
+	codewords := readQRCodewords(q)
+	w.Equal(qrAddECCAndInterleave(qrDataCodewords(t, text, q.Version, QRLevelH), q.Version, QRLevelH), codewords)
+
+	_, err = EncodeQR(strings.Repeat("x", 3000), QRLevelL)
+	w.True(err == ErrQRDataTooLong, err)
+	q, err = EncodeQR(strings.Repeat("7", 4296), QRLevelL)
+	w.Nil(err).Equal(40, q.Version)
+
+	var buf bytes.Buffer
+	w.Nil(q.WritePNG(&buf, 2, 4))
+	img, err := png.Decode(&buf)
+	w.Nil(err).Equal((q.Size+8)*2, img.Bounds().Dx())
+	w.Equal(q.Size+2, strings.Count(q.Text(1), "\n"))
+}
+
+// qrDataCodewords 重新计算byte模式的数据码字(不含纠错码)
+func qrDataCodewords(t *testing.T, text string, version int, level QRLevel) []byte {
+	var bb qrBitBuffer
+	bb.append(0x4, 4)
+	bb.append(len(text), qrCharCountBits(false, version))
+	for i := 0; i < len(text); i++ {
+		bb.append(int(text[i]), 8)
+	}
+	bb.append(0, 4)
+	bb.append(0, (8-len(bb)%8)%8)
+	for pad := 0xEC; len(bb) < qrNumDataCodewords(version, level)*8; pad ^= 0xEC ^ 0x11 {
+		bb.append(pad, 8)
+	}
+	b := make([]byte, len(bb)/8)
+	for i, bit := range bb {
+		if bit {
+			b[i/8] |= 1 << uint(7-i%8)
+		}
+	}
+	return b
+}
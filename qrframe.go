@@ -0,0 +1,216 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultQRFrameLen 每帧的默认数据字符数, 使用 QRLevelM 时约为 version 13 的二维码
+const DefaultQRFrameLen = 300
+
+// qrFramePrefix 帧格式: BBC:<kind>/<seq>-<total>/<crc32>/<data>,
+// seq 从1开始, crc32 为完整数据的校验和(同时作为消息id), data 为完整数据base32编码后的第seq段;
+// 所有字符都在二维码 alphanumeric 字符集内
+const qrFramePrefix = "BBC:"
+
+// QRFrameKind 多帧二维码传输的数据类型
+type QRFrameKind string
+
+// 数据类型
+const (
+	QRFrameEnvelope QRFrameKind = "ENV" //TXEnvelope.Bytes()
+	QRFrameTx       QRFrameKind = "TX"  //序列化后的交易(可含签名)
+)
+
+// EncodeQRFrames 将数据拆分为多帧, frameLen 为每帧的数据字符数(<=0 时使用 DefaultQRFrameLen)
+func EncodeQRFrames(kind QRFrameKind, payload []byte, frameLen int) ([]string, error) {
+	if kind == "" || strings.ContainsAny(string(kind), "/:") || !qrIsAlphanumeric(string(kind)) {
+		return nil, fmt.Errorf("invalid frame kind %q", kind)
+	}
+	if len(payload) == 0 {
+		return nil, errors.New("empty payload")
+	}
+	if frameLen <= 0 {
+		frameLen = DefaultQRFrameLen
+	}
+	data := txEnvelopeBase32.EncodeToString(payload)
+	total := (len(data) + frameLen - 1) / frameLen
+	id := fmt.Sprintf("%08X", crc32.ChecksumIEEE(payload))
+	frames := make([]string, 0, total)
+	for i := 0; i < total; i++ {
+		end := qrMin((i+1)*frameLen, len(data))
+		frames = append(frames, fmt.Sprintf("%s%s/%d-%d/%s/%s", qrFramePrefix, kind, i+1, total, id, data[i*frameLen:end]))
+	}
+	return frames, nil
+}
+
+// QRFrames TXEnvelope 的多帧编码
+func (e *TXEnvelope) QRFrames(frameLen int) ([]string, error) {
+	b, err := e.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	return EncodeQRFrames(QRFrameEnvelope, b, frameLen)
+}
+
+// TxQRFrames 交易(含签名数据)的多帧编码
+func TxQRFrames(serializer Serializer, rtx *RawTransaction, frameLen int) ([]string, error) {
+	b, err := rtx.EncodeBytes(serializer, true)
+	if err != nil {
+		return nil, err
+	}
+	return EncodeQRFrames(QRFrameTx, b, frameLen)
+}
+
+// RenderQRFrames 为每帧生成二维码
+func RenderQRFrames(frames []string, level QRLevel) ([]*QRCode, error) {
+	codes := make([]*QRCode, len(frames))
+	for i, f := range frames {
+		q, err := EncodeQR(f, level)
+		if err != nil {
+			return nil, errors.Wrapf(err, "frame %d", i+1)
+		}
+		codes[i] = q
+	}
+	return codes, nil
+}
+
+// QRFrameDecoder 接收任意顺序(可重复)的帧, 全部收到后还原数据
+type QRFrameDecoder struct {
+	kind  QRFrameKind
+	id    string
+	total int
+	parts map[int]string
+}
+
+// NewQRFrameDecoder .
+func NewQRFrameDecoder() *QRFrameDecoder {
+	return &QRFrameDecoder{parts: map[int]string{}}
+}
+
+// Add 添加一帧, 返回是否为新的帧; 帧与之前的帧不属于同一数据时返回错误(可 Reset 后重新开始)
+func (d *QRFrameDecoder) Add(frame string) (bool, error) {
+	kind, seq, total, id, data, err := parseQRFrame(frame)
+	if err != nil {
+		return false, err
+	}
+	if d.total == 0 {
+		d.kind, d.total, d.id = kind, total, id
+	} else if kind != d.kind || total != d.total || id != d.id {
+		return false, fmt.Errorf("frame %s/%d-%d/%s does not belong to %s/%d/%s", kind, seq, total, id, d.kind, d.total, d.id)
+	}
+	if old, ok := d.parts[seq]; ok {
+		if old != data {
+			return false, fmt.Errorf("conflicting data for frame %d", seq)
+		}
+		return false, nil
+	}
+	d.parts[seq] = data
+	return true, nil
+}
+
+// Reset 清除已接收的帧
+func (d *QRFrameDecoder) Reset() {
+	*d = *NewQRFrameDecoder()
+}
+
+// Progress 已接收的帧数和总帧数(未收到任何帧时为0, 0)
+func (d *QRFrameDecoder) Progress() (received, total int) {
+	return len(d.parts), d.total
+}
+
+// Complete 是否已收到全部帧
+func (d *QRFrameDecoder) Complete() bool {
+	return d.total > 0 && len(d.parts) == d.total
+}
+
+// Missing 未收到的帧序号
+func (d *QRFrameDecoder) Missing() []int {
+	var ret []int
+	for i := 1; i <= d.total; i++ {
+		if _, ok := d.parts[i]; !ok {
+			ret = append(ret, i)
+		}
+	}
+	return ret
+}
+
+// Result 还原数据并校验crc32
+func (d *QRFrameDecoder) Result() (QRFrameKind, []byte, error) {
+	if !d.Complete() {
+		received, total := d.Progress()
+		return "", nil, fmt.Errorf("incomplete frames: %d/%d", received, total)
+	}
+	seqs := make([]int, 0, len(d.parts))
+	for seq := range d.parts {
+		seqs = append(seqs, seq)
+	}
+	sort.Ints(seqs)
+	var sb strings.Builder
+	for _, seq := range seqs {
+		sb.WriteString(d.parts[seq])
+	}
+	payload, err := txEnvelopeBase32.DecodeString(sb.String())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "invalid frame data")
+	}
+	if id := fmt.Sprintf("%08X", crc32.ChecksumIEEE(payload)); id != d.id {
+		return "", nil, fmt.Errorf("frame data checksum mismatch, expected %s, got %s", d.id, id)
+	}
+	return d.kind, payload, nil
+}
+
+// Envelope 还原 TXEnvelope
+func (d *QRFrameDecoder) Envelope() (*TXEnvelope, error) {
+	kind, payload, err := d.Result()
+	if err != nil {
+		return nil, err
+	}
+	if kind != QRFrameEnvelope {
+		return nil, fmt.Errorf("frames contain %s, not %s", kind, QRFrameEnvelope)
+	}
+	return ParseTXEnvelopeBytes(payload)
+}
+
+// Transaction 还原交易
+func (d *QRFrameDecoder) Transaction(serializer Serializer) (*Transaction, error) {
+	kind, payload, err := d.Result()
+	if err != nil {
+		return nil, err
+	}
+	if kind != QRFrameTx {
+		return nil, fmt.Errorf("frames contain %s, not %s", kind, QRFrameTx)
+	}
+	return DecodeRawTransaction(serializer, hex.EncodeToString(payload), true)
+}
+
+func parseQRFrame(frame string) (kind QRFrameKind, seq, total int, id, data string, err error) {
+	frame = strings.TrimSpace(frame)
+	if !strings.HasPrefix(frame, qrFramePrefix) {
+		return "", 0, 0, "", "", errors.New("not a qr frame")
+	}
+	parts := strings.Split(frame[len(qrFramePrefix):], "/")
+	if len(parts) != 4 {
+		return "", 0, 0, "", "", errors.New("invalid qr frame format")
+	}
+	st := strings.Split(parts[1], "-")
+	if len(st) != 2 {
+		return "", 0, 0, "", "", errors.New("invalid qr frame sequence")
+	}
+	if seq, err = strconv.Atoi(st[0]); err == nil {
+		total, err = strconv.Atoi(st[1])
+	}
+	if err != nil || total < 1 || seq < 1 || seq > total {
+		return "", 0, 0, "", "", fmt.Errorf("invalid qr frame sequence %s", parts[1])
+	}
+	if len(parts[2]) != 8 || len(parts[3]) == 0 {
+		return "", 0, 0, "", "", errors.New("invalid qr frame id or data")
+	}
+	return QRFrameKind(parts[0]), seq, total, parts[2], parts[3], nil
+}
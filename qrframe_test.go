@@ -0,0 +1,105 @@
+package gobbc
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestQRFrames(t *testing.T) {
+	w := TW{T: t}
+	keys := make([]AddrKeyPair, 3)
+	for i := range keys {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys[i] = k
+	}
+	templates, err := ParseTemplateList(testMultisigTplHex(t, 2, keys[0].Pubk, keys[1].Pubk, keys[2].Pubk))
+	w.Nil(err)
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+		SetAddress(keys[0].Addr).
+		SetAmount(1).SetFee(0.03).
+		SetData("text", []byte("air-gapped")).
+		Build()
+	w.Nil(err)
+	w.Nil(rtx.SignWithTemplates(BBCSerializer, templates, keys[0].Privk))
+	w.Nil(rtx.SignWithTemplates(BBCSerializer, templates, keys[1].Privk))
+	e, err := NewTXEnvelope(BBCMainnet, rtx, templates, &TXMeta{Description: "multisig"})
+	w.Nil(err)
+
+	frames, err := e.QRFrames(100)
+	w.Nil(err).True(len(frames) > 3, len(frames))
+	for _, f := range frames {
+		w.True(qrIsAlphanumeric(f), f)
+	}
+
+	// 乱序、重复
+	d := NewQRFrameDecoder()
+	received, total := d.Progress()
+	w.Equal(0, received).Equal(0, total).True(!d.Complete())
+	order := rand.New(rand.NewSource(1)).Perm(len(frames))
+	for i, idx := range order {
+		added, err := d.Add(frames[idx])
+		w.Nil(err).True(added)
+		added, err = d.Add(frames[idx])
+		w.Nil(err).True(!added)
+		received, total = d.Progress()
+		w.Equal(i+1, received).Equal(len(frames), total)
+		if i == 0 {
+			w.Equal(len(frames)-1, len(d.Missing()))
+			_, err = d.Envelope()
+			w.True(err != nil, "incomplete")
+		}
+	}
+	w.True(d.Complete()).Equal(0, len(d.Missing()))
+	got, err := d.Envelope()
+	w.Nil(err).Equal(e, got)
+	_, err = d.Transaction(BBCSerializer)
+	w.True(err != nil, "kind")
+
+	// 不同数据的帧
+	txFrames, err := TxQRFrames(BBCSerializer, rtx, 0)
+	w.Nil(err)
+	_, err = d.Add(txFrames[0])
+	w.True(err != nil, "other data")
+	d.Reset()
+	for _, f := range txFrames {
+		_, err = d.Add(f)
+		w.Nil(err)
+	}
+	tx, err := d.Transaction(BBCSerializer)
+	w.Nil(err).Equal(rtx.SignBytes, tx.SignBytes)
+
+	// 数据被修改
+	d.Reset()
+	for i, f := range frames {
+		if i == 1 {
+			last := f[len(f)-1]
+			c := "A"
+			if last == 'A' {
+				c = "B"
+			}
+			f = f[:len(f)-1] + c
+		}
+		_, err = d.Add(f)
+		w.Nil(err)
+	}
+	_, _, err = d.Result()
+	w.True(err != nil, "checksum")
+
+	for _, f := range []string{"", "BBC:ENV/1-2/0000", "BBC:ENV/3-2/00000000/AA", "BBC:ENV/0-2/00000000/AA", "bbc:ENV/1-1/00000000/AA"} {
+		_, err = d.Add(f)
+		w.True(err != nil, f)
+	}
+	_, err = EncodeQRFrames("E/V", []byte{1}, 0)
+	w.True(err != nil)
+
+	codes, err := RenderQRFrames(frames, QRLevelM)
+	w.Nil(err).Equal(len(frames), len(codes))
+	w.True(codes[0].Version <= 6, codes[0].Version)
+	long, err := EncodeQRFrames(QRFrameTx, []byte(strings.Repeat("x", 300)), 0)
+	w.Nil(err).Equal(2, len(long))
+}
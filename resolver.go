@@ -0,0 +1,209 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTemplateNotFound resolver 中没有该模版地址的模版数据
+var ErrTemplateNotFound = errors.New("template data not found")
+
+// TemplateDataResolver 根据模版地址获取模版数据(hex, 含2字节类型, 即rpc validateaddress 返回的 templatedata.hex)
+type TemplateDataResolver interface {
+	ResolveTemplateData(address string) (string, error)
+}
+
+// TemplateResolverFunc 使用函数作为 TemplateDataResolver, 如通过rpc validateaddress 查询
+type TemplateResolverFunc func(address string) (string, error)
+
+// ResolveTemplateData .
+func (f TemplateResolverFunc) ResolveTemplateData(address string) (string, error) { return f(address) }
+
+// MemoryTemplateResolver 内存中的模版数据, 添加时根据模版数据计算地址
+type MemoryTemplateResolver struct {
+	mu        sync.RWMutex
+	byAddress map[string]string
+}
+
+// NewMemoryTemplateResolver .
+func NewMemoryTemplateResolver(tplHexList ...string) (*MemoryTemplateResolver, error) {
+	r := &MemoryTemplateResolver{byAddress: map[string]string{}}
+	for _, tpl := range tplHexList {
+		if _, err := r.Add(tpl); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// LoadTemplateResolverFile 从json文件加载模版数据, 格式: ["模版数据hex", ...]
+func LoadTemplateResolverFile(path string) (*MemoryTemplateResolver, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r, _ := NewMemoryTemplateResolver()
+	return r, r.Load(f)
+}
+
+// Load 加载json格式的模版数据: ["模版数据hex", ...]
+func (r *MemoryTemplateResolver) Load(rd io.Reader) error {
+	var list []string
+	if err := json.NewDecoder(rd).Decode(&list); err != nil {
+		return errors.Wrap(err, "failed to decode template data list")
+	}
+	for _, tpl := range list {
+		if _, err := r.Add(tpl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Add 添加模版数据, 返回模版地址
+func (r *MemoryTemplateResolver) Add(tplHex string) (string, error) {
+	addr, err := TemplateAddress(tplHex)
+	if err != nil {
+		return "", err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byAddress[addr] = strings.ToLower(tplHex)
+	return addr, nil
+}
+
+// ResolveTemplateData .
+func (r *MemoryTemplateResolver) ResolveTemplateData(address string) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tpl, ok := r.byAddress[address]
+	if !ok {
+		return "", errors.Wrap(ErrTemplateNotFound, address)
+	}
+	return tpl, nil
+}
+
+// maxTemplateDepth 模版嵌套(如 delegate -> 多签 owner)的最大层数
+const maxTemplateDepth = 4
+
+// resolveTemplate 获取模版数据并校验与地址一致
+func resolveTemplate(resolver TemplateDataResolver, dest CDestination) (string, error) {
+	addr := dest.String()
+	if resolver == nil {
+		return "", fmt.Errorf("template resolver required for %s", addr)
+	}
+	tpl, err := resolver.ResolveTemplateData(addr)
+	if err != nil {
+		return "", err
+	}
+	if got, err := TemplateAddress(tpl); err != nil {
+		return "", err
+	} else if got != addr {
+		return "", fmt.Errorf("template data of %s does not match address %s", addr, got)
+	}
+	return tpl, nil
+}
+
+// templateOwner 从模版转出时的签名者(可能也是模版地址), 多签等由私钥直接签名的模版返回false
+func templateOwner(tplHex string) (CDestination, bool, error) {
+	switch GetTemplateType(tplHex) {
+	case TemplateTypeDelegate:
+		tpl, err := ParseDelegateTemplateHex(tplHex)
+		if err != nil {
+			return CDestination{}, false, err
+		}
+		return tpl.Owner, true, nil
+	case TemplateTypeVote:
+		tpl, err := ParseVoteTemplateHex(tplHex)
+		if err != nil {
+			return CDestination{}, false, err
+		}
+		return tpl.Voter, true, nil
+	case TemplateTypeProof:
+		tpl, err := ParseProofTemplateHex(tplHex)
+		if err != nil {
+			return CDestination{}, false, err
+		}
+		return tpl.Spent, true, nil
+	case TemplateTypeFork:
+		tpl, err := ParseForkTemplateHex(tplHex)
+		if err != nil {
+			return CDestination{}, false, err
+		}
+		return tpl.Redeem, true, nil
+	case templateDexorder:
+		tpl, err := ParseDexOrderTemplate(tplHex)
+		if err != nil {
+			return CDestination{}, false, err
+		}
+		dest, err := NewCDestinationFromAddress(string(tpl.MatchAddress))
+		return dest, err == nil, err
+	}
+	return CDestination{}, false, nil
+}
+
+// ResolveSignTemplates 根据from地址和tx的转账地址计算签名需要的模版数据列表(使用,分隔, 可直接传给 SignWithPrivateKey):
+// 1. 转账地址为投票模版时, 首先是投票模版数据
+// 2. from为模版地址时, 依次为from的模版数据以及其owner的模版数据(如 delegate模版,多签模版)
+func ResolveSignTemplates(rtx *RawTransaction, from string, resolver TemplateDataResolver) (string, error) {
+	var list []string
+	to := CDestination{Prefix: rtx.Prefix, Data: rtx.AddressBytes}
+	if to.TemplateType() == TemplateTypeVote {
+		tpl, err := resolveTemplate(resolver, to)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to resolve vote template of destination")
+		}
+		list = append(list, tpl)
+	}
+
+	dest, err := NewCDestinationFromAddress(from)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid from address")
+	}
+	for depth := 0; dest.IsTemplate(); depth++ {
+		if depth >= maxTemplateDepth {
+			return "", fmt.Errorf("template nesting of %s too deep", from)
+		}
+		tpl, err := resolveTemplate(resolver, dest)
+		if err != nil {
+			return "", errors.Wrap(err, "unable to resolve template of from address")
+		}
+		if len(list) == 0 || list[len(list)-1] != tpl { //投票赎回时from与转账地址可能为同一投票模版
+			list = append(list, tpl)
+		}
+		owner, ok, err := templateOwner(tpl)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			break
+		}
+		dest = owner
+	}
+	return strings.Join(list, TemplateDataSpliter), nil
+}
+
+// SignAuto 自动获取签名需要的模版数据(参考 ResolveSignTemplates)并使用私钥依次签名,
+// 多签时传入多个私钥, 其他情况只能传入一个私钥
+func (rtx *RawTransaction) SignAuto(serializer Serializer, from string, resolver TemplateDataResolver, privks ...string) error {
+	if len(privks) == 0 {
+		return errors.New("no private key provided")
+	}
+	tpls, err := ResolveSignTemplates(rtx, from, resolver)
+	if err != nil {
+		return err
+	}
+	for _, privk := range privks {
+		if err := rtx.SignWithPrivateKey(serializer, tpls, privk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
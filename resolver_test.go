@@ -0,0 +1,133 @@
+package gobbc
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testMultisigTplHex |type|M|N(uint64)|pubk+weight...|
+func testMultisigTplHex(t *testing.T, m uint8, pubks ...string) string {
+	b := append(appendUint16(nil, uint16(TemplateTypeMultisig)), m)
+	b = appendUint64(b, uint64(len(pubks)))
+	for _, p := range pubks {
+		pubk, err := ParsePublicKeyHex(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b = append(append(b, pubk...), 1)
+	}
+	return hex.EncodeToString(b)
+}
+
+func TestSignAuto(t *testing.T) {
+	w := TW{T: t}
+	var keys []AddrKeyPair
+	for i := 0; i < 4; i++ {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys = append(keys, k)
+	}
+	multisigTpl := testMultisigTplHex(t, 2, keys[0].Pubk, keys[1].Pubk)
+	multisigAddr, err := TemplateAddress(multisigTpl)
+	w.Nil(err)
+	multisigDest, err := NewCDestinationFromAddress(multisigAddr)
+	w.Nil(err)
+	delegateAddr, delegateTpl, err := CreateTemplateDataDelegate(keys[2].Pubk, multisigDest)
+	w.Nil(err)
+	delegateDest, err := NewCDestinationFromAddress(delegateAddr)
+	w.Nil(err)
+	voterDest, err := NewCDestinationFromAddress(keys[3].Addr)
+	w.Nil(err)
+	voteAddr, voteTpl, err := CreateTemplateDataVote(VoteTpl{Delegate: delegateDest, Voter: voterDest})
+	w.Nil(err).True(strings.HasPrefix(voteAddr, "20w0"), voteAddr)
+	vote, err := ParseVoteTemplateHex(voteTpl)
+	w.Nil(err).Equal(delegateDest, vote.Delegate).Equal(voterDest, vote.Voter)
+	_, _, err = CreateTemplateDataVote(VoteTpl{Delegate: voterDest, Voter: voterDest})
+	w.True(err != nil, "delegate should be template")
+
+	resolver, err := NewMemoryTemplateResolver(multisigTpl, delegateTpl, voteTpl)
+	w.Nil(err)
+
+	build := func(to string) *RawTransaction {
+		rtx, err := NewTXBuilder().
+			SetAnchor(BBCMainnet.GenesisAnchor).
+			SetTimestamp(1590474715).
+			AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+			SetAddress(to).
+			SetAmount(1).SetFee(0.01).
+			Build()
+		w.Nil(err)
+		return rtx
+	}
+
+	for _, tt := range []struct {
+		name, from, to string
+		expected       []string
+		privks         []string
+	}{
+		{"pubkey", keys[3].Addr, keys[0].Addr, nil, []string{keys[3].Privk}},
+		{"vote", keys[3].Addr, voteAddr, []string{voteTpl}, []string{keys[3].Privk}},
+		{"redeem vote", voteAddr, keys[3].Addr, []string{voteTpl}, []string{keys[3].Privk}},
+		{"vote more", voteAddr, voteAddr, []string{voteTpl}, []string{keys[3].Privk}},
+		{"multisig", multisigAddr, keys[3].Addr, []string{multisigTpl}, []string{keys[0].Privk, keys[1].Privk}},
+		{"multisig delegate", delegateAddr, keys[3].Addr, []string{delegateTpl, multisigTpl}, []string{keys[0].Privk, keys[1].Privk}},
+		{"vote from multisig delegate", delegateAddr, voteAddr, []string{voteTpl, delegateTpl, multisigTpl}, []string{keys[1].Privk, keys[0].Privk}},
+	} {
+		rtx := build(tt.to)
+		tpls, err := ResolveSignTemplates(rtx, tt.from, resolver)
+		w.Nil(err, tt.name).Equal(strings.Join(tt.expected, ","), tpls, tt.name)
+		w.Nil(rtx.SignAuto(BBCSerializer, tt.from, resolver, tt.privks...), tt.name)
+
+		expected := build(tt.to)
+		for _, k := range tt.privks {
+			w.Nil(expected.SignWithPrivateKey(BBCSerializer, tpls, k))
+		}
+		w.Equal(expected.SignBytes, rtx.SignBytes, tt.name)
+	}
+
+	rtx := build(keys[0].Addr)
+	w.True(rtx.SignAuto(BBCSerializer, keys[3].Addr, resolver, keys[3].Privk, keys[0].Privk) != nil, "single sig with 2 keys")
+	w.True(build(keys[0].Addr).SignAuto(BBCSerializer, keys[3].Addr, resolver) != nil, "no key")
+
+	empty, err := NewMemoryTemplateResolver()
+	w.Nil(err)
+	_, err = ResolveSignTemplates(build(keys[0].Addr), delegateAddr, empty)
+	w.True(errors.Is(err, ErrTemplateNotFound), err)
+	_, err = ResolveSignTemplates(build(voteAddr), keys[3].Addr, nil)
+	w.True(err != nil, "nil resolver")
+	wrong := TemplateResolverFunc(func(string) (string, error) { return multisigTpl, nil })
+	_, err = ResolveSignTemplates(build(keys[0].Addr), delegateAddr, wrong)
+	w.True(err != nil, "template mismatch")
+}
+
+func TestLoadTemplateResolverFile(t *testing.T) {
+	w := TW{T: t}
+	owner, err := NewCDestinationFromAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm")
+	w.Nil(err)
+	addr, tpl, err := CreateTemplateDataFork(owner, BBCMainnet.GenesisAnchor)
+	w.Nil(err)
+
+	dir, err := ioutil.TempDir("", "gobbc")
+	w.Nil(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "templates.json")
+	b, _ := json.Marshal([]string{tpl})
+	w.Nil(ioutil.WriteFile(path, b, 0600))
+
+	r, err := LoadTemplateResolverFile(path)
+	w.Nil(err)
+	got, err := r.ResolveTemplateData(addr)
+	w.Nil(err).Equal(tpl, got)
+
+	w.Nil(ioutil.WriteFile(path, []byte(`["00"]`), 0600))
+	_, err = LoadTemplateResolverFile(path)
+	w.True(err != nil)
+	_, err = LoadTemplateResolverFile(filepath.Join(dir, "not-exists.json"))
+	w.True(err != nil)
+}
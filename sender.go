@@ -0,0 +1,143 @@
+package gobbc
+
+import (
+	"crypto/ed25519"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSenderUnknown 无法确定交易的转出地址
+var ErrSenderUnknown = errors.New("sender address unknown")
+
+// InputAddressResolver 查询交易输入(txid, vout)的地址, 如通过rpc gettransaction 查询输入交易的输出
+type InputAddressResolver interface {
+	ResolveInputAddress(txid string, vout int) (string, error)
+}
+
+// InputResolverFunc 使用函数作为 InputAddressResolver
+type InputResolverFunc func(txid string, vout int) (string, error)
+
+// ResolveInputAddress .
+func (f InputResolverFunc) ResolveInputAddress(txid string, vout int) (string, error) {
+	return f(txid, vout)
+}
+
+// SenderResolver 公钥地址转出时确定from地址的方式, 依次尝试:
+// 1. Inputs 不为空时查询输入的地址
+// 2. 未设置 Inputs 或无法通过 Inputs 确定时, 使用 Candidates 中的公钥地址校验签名, 通过的即为from
+// 签名数据中的模版数据格式有歧义(ErrSignDataAmbiguous)时, Candidates 中的地址以及 Inputs 查询到的地址作为 ParseSignData 的 hints
+type SenderResolver struct {
+	Serializer Serializer //校验签名时计算tx hash, 使用 Candidates 中的公钥地址时需要
+	Candidates []string   //候选地址
+	Inputs     InputAddressResolver
+}
+
+// SenderAddress 交易的转出地址(from):
+// - 模版地址转出时签名数据包含from的模版数据, 不需要额外信息(resolver 可为nil)
+// - 公钥地址转出时使用 resolver 确定
+// 转账地址为投票模版且签名数据中只有该投票模版时, from可能是公钥地址或该投票模版(追加投票), 使用 resolver 确定, 无法确定时视为公钥地址;
+// 未签名的交易只能通过 resolver.Inputs 确定
+func (rtx *RawTransaction) SenderAddress(resolver *SenderResolver) (string, error) {
+	if len(rtx.SignBytes) == 0 {
+		if resolver == nil || resolver.Inputs == nil {
+			return "", ErrSenderUnknown
+		}
+		return rtx.inputsAddress(resolver.Inputs)
+	}
+	to := CDestination{Prefix: rtx.Prefix, Data: rtx.AddressBytes}
+	templates, _, err := rtx.SignData()
+	if errors.Is(err, ErrSignDataAmbiguous) && resolver != nil {
+		hints := append([]string{to.String()}, resolver.Candidates...)
+		if resolver.Inputs != nil {
+			if from, e := rtx.inputsAddress(resolver.Inputs); e == nil {
+				hints = append(hints, from)
+			}
+		}
+		templates, _, err = ParseSignData(rtx.SignBytes, hints...)
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "unable to parse sign data")
+	}
+	voteTo := len(templates) > 0 && to.TemplateType() == TemplateTypeVote && templates[0].Address() == to.String()
+	if voteTo {
+		templates = templates[1:]
+	}
+	if len(templates) > 0 {
+		return templates[0].Address(), nil
+	}
+	if resolver == nil {
+		return "", ErrSenderUnknown
+	}
+	if resolver.Inputs == nil {
+		return rtx.verifySender(resolver.Serializer, resolver.Candidates)
+	}
+	from, err := rtx.inputsAddress(resolver.Inputs)
+	if err == nil || len(resolver.Candidates) == 0 {
+		return from, err
+	}
+	if from, e := rtx.verifySender(resolver.Serializer, resolver.Candidates); e == nil {
+		return from, nil
+	}
+	return "", err
+}
+
+// inputsAddress 所有输入的地址, 应该相同
+func (rtx *RawTransaction) inputsAddress(inputs InputAddressResolver) (string, error) {
+	var from string
+	for _, vin := range rtx.ToTransaction(false).Vin {
+		addr, err := inputs.ResolveInputAddress(vin.Txid, vin.Vout)
+		if err != nil {
+			return "", errors.Wrapf(err, "unable to resolve address of input %s:%d", vin.Txid, vin.Vout)
+		}
+		if from != "" && addr != from {
+			return "", fmt.Errorf("inputs from different addresses: %s, %s", from, addr)
+		}
+		from = addr
+	}
+	if from == "" {
+		return "", ErrSenderUnknown
+	}
+	return from, nil
+}
+
+// verifySender 签名(最后64字节)可以被候选公钥验证的地址
+func (rtx *RawTransaction) verifySender(serializer Serializer, candidates []string) (string, error) {
+	if len(candidates) == 0 {
+		return "", ErrSenderUnknown
+	}
+	if serializer == nil {
+		return "", errors.New("serializer required to verify signature")
+	}
+	if len(rtx.SignBytes) < ed25519.SignatureSize {
+		return "", errors.New("invalid signature length")
+	}
+	txHash, err := rtx.TxHash(serializer)
+	if err != nil {
+		return "", err
+	}
+	sig := rtx.SignBytes[len(rtx.SignBytes)-ed25519.SignatureSize:]
+	for _, addr := range candidates {
+		dest, err := NewCDestinationFromAddress(addr)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid candidate address %s", addr)
+		}
+		if dest.Prefix != PrefixPubk {
+			continue
+		}
+		if ed25519.Verify(ed25519.PublicKey(dest.Data[:]), txHash[:], sig) {
+			return addr, nil
+		}
+	}
+	return "", ErrSenderUnknown
+}
+
+// ResolveFrom 使用 SenderAddress 填充 From
+func (tx *Transaction) ResolveFrom(resolver *SenderResolver) error {
+	from, err := tx.RawTransaction.SenderAddress(resolver)
+	if err != nil {
+		return err
+	}
+	tx.From = from
+	return nil
+}
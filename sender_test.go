@@ -0,0 +1,114 @@
+package gobbc
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSenderAddress(t *testing.T) {
+	w := TW{T: t}
+	var keys []AddrKeyPair
+	for i := 0; i < 3; i++ {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys = append(keys, k)
+	}
+	multisigTpl := testMultisigTplHex(t, 1, keys[0].Pubk, keys[1].Pubk)
+	multisigAddr, err := TemplateAddress(multisigTpl)
+	w.Nil(err)
+	multisigDest, err := NewCDestinationFromAddress(multisigAddr)
+	w.Nil(err)
+	delegateAddr, delegateTpl, err := CreateTemplateDataDelegate(keys[2].Pubk, multisigDest)
+	w.Nil(err)
+	delegateDest, err := NewCDestinationFromAddress(delegateAddr)
+	w.Nil(err)
+	voterDest, err := NewCDestinationFromAddress(keys[2].Addr)
+	w.Nil(err)
+	voteAddr, voteTpl, err := CreateTemplateDataVote(VoteTpl{Delegate: delegateDest, Voter: voterDest})
+	w.Nil(err)
+	proofAddr, proofTpl, err := CreateTemplateDataProof(keys[0].Pubk, voterDest)
+	w.Nil(err)
+	resolver, err := NewMemoryTemplateResolver(multisigTpl, delegateTpl, voteTpl, proofTpl)
+	w.Nil(err)
+
+	const input = "5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782"
+	unsigned := func(to string) *RawTransaction {
+		rtx, err := NewTXBuilder().
+			SetAnchor(BBCMainnet.GenesisAnchor).
+			SetTimestamp(1590474715).
+			AddInput(input, 1).
+			SetAddress(to).
+			SetAmount(1).SetFee(0.01).
+			Build()
+		w.Nil(err)
+		return rtx
+	}
+	signed := func(from, to string, privk string) *RawTransaction {
+		rtx := unsigned(to)
+		w.Nil(rtx.SignAuto(BBCSerializer, from, resolver, privk))
+		return rtx
+	}
+
+	// 模版地址转出, 模版数据格式有歧义(multisig与weighted, delegate与proof)时使用 Candidates 或 Inputs 确定
+	fixedInput := func(addr string) InputResolverFunc {
+		return func(string, int) (string, error) { return addr, nil }
+	}
+	for _, tt := range []struct {
+		name, from, to, privk string
+		ambiguous             bool
+	}{
+		{"multisig", multisigAddr, keys[2].Addr, keys[0].Privk, true},
+		{"delegate", delegateAddr, keys[2].Addr, keys[0].Privk, true},
+		{"vote from delegate", delegateAddr, voteAddr, keys[1].Privk, true},
+		{"proof", proofAddr, keys[0].Addr, keys[2].Privk, true},
+		{"redeem vote", voteAddr, keys[2].Addr, keys[2].Privk, false},
+	} {
+		rtx := signed(tt.from, tt.to, tt.privk)
+		from, err := rtx.SenderAddress(nil)
+		if tt.ambiguous {
+			w.True(errors.Is(err, ErrSignDataAmbiguous), tt.name, err)
+			from, err = rtx.SenderAddress(&SenderResolver{Candidates: []string{tt.from}})
+			w.Nil(err, tt.name).Equal(tt.from, from, tt.name)
+			from, err = rtx.SenderAddress(&SenderResolver{Inputs: fixedInput(tt.from)})
+		}
+		w.Nil(err, tt.name).Equal(tt.from, from, tt.name)
+	}
+
+	// 公钥地址转出
+	rtx := signed(keys[1].Addr, keys[0].Addr, keys[1].Privk)
+	_, err = rtx.SenderAddress(nil)
+	w.True(errors.Is(err, ErrSenderUnknown), err)
+	from, err := rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[0].Addr, multisigAddr, keys[1].Addr}})
+	w.Nil(err).Equal(keys[1].Addr, from)
+	_, err = rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[0].Addr}})
+	w.True(errors.Is(err, ErrSenderUnknown), err)
+	_, err = rtx.SenderAddress(&SenderResolver{Candidates: []string{keys[1].Addr}})
+	w.True(err != nil, "serializer required")
+
+	inputs := InputResolverFunc(func(txid string, vout int) (string, error) {
+		w.Equal(input, txid).Equal(1, vout)
+		return keys[1].Addr, nil
+	})
+	tx := rtx.ToTransaction(false)
+	w.Nil(tx.ResolveFrom(&SenderResolver{Inputs: inputs})).Equal(keys[1].Addr, tx.From)
+	// Inputs 无法确定时使用 Candidates
+	notFound := InputResolverFunc(func(txid string, vout int) (string, error) {
+		return "", errors.New("tx not found")
+	})
+	from, err = rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[1].Addr}, Inputs: notFound})
+	w.Nil(err).Equal(keys[1].Addr, from)
+	_, err = rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[0].Addr}, Inputs: notFound})
+	w.True(err != nil && !errors.Is(err, ErrSenderUnknown), err)
+
+	// 未签名时通过 Inputs 确定
+	rtx = unsigned(keys[0].Addr)
+	_, err = rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[1].Addr}})
+	w.True(errors.Is(err, ErrSenderUnknown), err)
+	from, err = rtx.SenderAddress(&SenderResolver{Inputs: inputs})
+	w.Nil(err).Equal(keys[1].Addr, from)
+
+	// 投票: 转账地址为投票模版, from为公钥地址
+	rtx = signed(keys[2].Addr, voteAddr, keys[2].Privk)
+	from, err = rtx.SenderAddress(&SenderResolver{Serializer: BBCSerializer, Candidates: []string{keys[2].Addr}})
+	w.Nil(err).Equal(keys[2].Addr, from)
+}
@@ -3,15 +3,72 @@ package gobbc
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"log"
-	"unsafe"
+	"math"
+	"sync"
 )
 
 var BBCSerializer Serializer = serializer{includeAnchor: true}
 var MKFSerializer Serializer = serializer{includeAnchor: false}
 
+// BBCStrictSerializer MKFStrictSerializer 使用 DefaultDecodeLimits 的严格模式序列化器,用于解析不可信来源的tx数据;
+// 保存的是初始化时 DefaultDecodeLimits 的副本, 之后修改 DefaultDecodeLimits 不影响这两个序列化器
+var (
+	BBCStrictSerializer Serializer = serializer{includeAnchor: true, limits: copyLimits(DefaultDecodeLimits)}
+	MKFStrictSerializer Serializer = serializer{includeAnchor: false, limits: copyLimits(DefaultDecodeLimits)}
+)
+
+// 严格模式下的错误类型, 通过 errors.Is 判断
+var (
+	ErrTruncated        = errors.New("unexpected end of data")
+	ErrTrailingData     = errors.New("trailing data after transaction")
+	ErrNonCanonicalSize = errors.New("non-canonical compact size")
+	ErrSizeLimit        = errors.New("size exceeds limit")
+)
+
+// DecodeLimits 严格模式反序列化时变长字段允许的最大值
+type DecodeLimits struct {
+	MaxInputs   uint64 //SizeIn 上限(笔数)
+	MaxDataSize uint64 //SizeOut 上限(vchData 字节数)
+	MaxSignSize uint64 //SizeSign 上限(签名数据字节数)
+}
+
+// DefaultDecodeLimits 参考core的 MAX_TX_SIZE (100000 bytes)
+var DefaultDecodeLimits = DecodeLimits{
+	MaxInputs:   100000 / 33,
+	MaxDataSize: 100000,
+	MaxSignSize: 100000,
+}
+
+// DecodeError 严格模式下的反序列化错误，包含出错的字段及其在数据中的偏移
+type DecodeError struct {
+	Field  string
+	Offset int
+	Err    error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("field %s at offset %d: %v", e.Field, e.Offset, e.Err)
+}
+
+func (e *DecodeError) Unwrap() error { return e.Err }
+
+// NewStrictSerializer 基于 BBCSerializer/MKFSerializer 创建严格模式的序列化器，
+// 反序列化时数据截断、存在多余的尾部数据、compact size 非最短编码、size 字段超出 limits 均返回 *DecodeError
+func NewStrictSerializer(base Serializer, limits DecodeLimits) (Serializer, error) {
+	s, err := streamConfig(base)
+	if err != nil {
+		return nil, err
+	}
+	s.limits = copyLimits(limits)
+	return s, nil
+}
+
+func copyLimits(limits DecodeLimits) *DecodeLimits { return &limits }
+
 // Serializer tx Serializer
 type Serializer interface {
 	Serialize(RawTransaction) ([]byte, error)
@@ -20,148 +77,375 @@ type Serializer interface {
 
 type serializer struct {
 	includeAnchor bool
+	limits        *DecodeLimits //not nil: strict mode
+}
+
+// streamConfig 获取 Serializer 对应的流式编解码配置
+func streamConfig(s Serializer) (serializer, error) {
+	switch x := s.(type) {
+	case serializer:
+		return x, nil
+	case *ChainParams:
+		return streamConfig(x.Serializer)
+	case ChainParams:
+		return streamConfig(x.Serializer)
+	default:
+		return serializer{}, fmt.Errorf("unsupported serializer %T", s)
+	}
 }
 
 func (s serializer) Serialize(rtx RawTransaction) ([]byte, error) {
-	buf := bytes.NewBuffer(nil)
+	return s.appendSerialize(make([]byte, 0, s.size(&rtx)), &rtx), nil
+}
 
-	var errs []error
+// size 序列化后的字节数
+func (s serializer) size(rtx *RawTransaction) int {
+	n := 2 + 2 + 4 + 4 + 1 + 32 + 8 + 8
+	if s.includeAnchor {
+		n += 32
+	}
+	return n + CompactSizeLen(rtx.SizeIn) + len(rtx.Input) +
+		CompactSizeLen(rtx.SizeOut) + len(rtx.VchData) +
+		CompactSizeLen(rtx.SizeSign) + len(rtx.SignBytes)
+}
 
-	write := func(v interface{}) {
-		if e := binary.Write(buf, binary.LittleEndian, v); e != nil {
-			errs = append(errs, e)
-		}
+// appendSerialize 按固定偏移写入各字段，不使用反射
+func (s serializer) appendSerialize(dst []byte, rtx *RawTransaction) []byte {
+	var b [12]byte
+	binary.LittleEndian.PutUint16(b[0:], rtx.Version)
+	binary.LittleEndian.PutUint16(b[2:], rtx.Typ)
+	binary.LittleEndian.PutUint32(b[4:], rtx.Timestamp)
+	binary.LittleEndian.PutUint32(b[8:], rtx.LockUntil)
+	dst = append(dst, b[:12]...)
+	if s.includeAnchor {
+		dst = append(dst, rtx.HashAnchorBytes[:]...)
 	}
-	fnWriteSize := func(size uint64) {
-		if e := writeSize(size, buf); e != nil {
-			errs = append(errs, e)
-		}
+	dst = AppendCompactSize(dst, rtx.SizeIn)
+	dst = append(dst, rtx.Input...) //:33*int(rtx.SizeIn)
+	dst = append(dst, rtx.Prefix)
+	dst = append(dst, rtx.AddressBytes[:]...)
+	binary.LittleEndian.PutUint64(b[0:], uint64(rtx.Amount))
+	dst = append(dst, b[:8]...)
+	binary.LittleEndian.PutUint64(b[0:], uint64(rtx.TxFee))
+	dst = append(dst, b[:8]...)
+	dst = AppendCompactSize(dst, rtx.SizeOut)
+	dst = append(dst, rtx.VchData...)
+	dst = AppendCompactSize(dst, rtx.SizeSign)
+	return append(dst, rtx.SignBytes...)
+}
+
+func (s serializer) Deserialize(b []byte) (RawTransaction, error) {
+	r := bytes.NewReader(b)
+	d := &Decoder{r: r, s: s}
+	tx, err := d.Decode()
+	if err == io.EOF {
+		err = d.fail("Version", io.ErrUnexpectedEOF)
 	}
-	write(rtx.Version)
-	write(rtx.Typ)
-	write(rtx.Timestamp)
-	write(rtx.LockUntil)
-	if s.includeAnchor {
-		buf.Write(rtx.HashAnchorBytes[:])
+	if err == nil && s.limits != nil && r.Len() > 0 {
+		err = &DecodeError{Field: "EOF", Offset: int(d.off), Err: ErrTrailingData}
 	}
-	fnWriteSize(rtx.SizeIn)
+	return tx, err
+}
+
+// Encoder 将 RawTransaction 依次序列化写入 io.Writer
+type Encoder struct {
+	w   io.Writer
+	s   serializer
+	err error
+}
+
+// NewEncoder serializer: BBCSerializer, MKFSerializer 等本包提供的 Serializer
+func NewEncoder(w io.Writer, serializer Serializer) *Encoder {
+	s, err := streamConfig(serializer)
+	return &Encoder{w: w, s: s, err: err}
+}
 
-	buf.Write(rtx.Input) //:33*int(rtx.SizeIn)
-	write(rtx.Prefix)
-	buf.Write(rtx.AddressBytes[:])
-	write(rtx.Amount)
-	write(rtx.TxFee)
-	fnWriteSize(rtx.SizeOut)
-	buf.Write(rtx.VchData)
-	fnWriteSize(rtx.SizeSign)
-	buf.Write(rtx.SignBytes)
+var encodeBufPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 512)
+		return &b
+	},
+}
 
-	var err error
-	if len(errs) != 0 {
-		err = fmt.Errorf("some errors when write binary: %v", errs)
+// Encode 序列化 rtx 并写入
+func (e *Encoder) Encode(rtx RawTransaction) error {
+	if e.err != nil {
+		return e.err
 	}
-	return buf.Bytes(), err
+	bp := encodeBufPool.Get().(*[]byte)
+	b := e.s.appendSerialize((*bp)[:0], &rtx)
+	_, err := e.w.Write(b)
+	*bp = b
+	encodeBufPool.Put(bp)
+	return err
 }
 
-func (s serializer) Deserialize(b []byte) (RawTransaction, error) {
-	var errs []error
-	var err error
+// Decoder 从 io.Reader 中依次读取 RawTransaction,
+// 使用严格模式的 Serializer 时错误为 *DecodeError, Offset 为相对于流起始位置的偏移
+type Decoder struct {
+	r   io.Reader
+	s   serializer
+	off int64
+	err error
+	buf [8]byte
+}
 
+// NewDecoder serializer: BBCSerializer, MKFSerializer, BBCStrictSerializer 等本包提供的 Serializer
+func NewDecoder(r io.Reader, serializer Serializer) *Decoder {
+	s, err := streamConfig(serializer)
+	return &Decoder{r: r, s: s, err: err}
+}
+
+// Offset 已读取的字节数
+func (d *Decoder) Offset() int64 { return d.off }
+
+// Decode 读取下一个tx, 数据流在tx边界结束时返回 io.EOF
+func (d *Decoder) Decode() (RawTransaction, error) {
 	var tx RawTransaction
-	buffer := bytes.NewBuffer(b)
-	readValue := func(v interface{}) {
-		if e := binary.Read(buffer, binary.LittleEndian, v); e != nil {
-			if Debug {
-				log.Printf("[ERR]解析tx数据时无法读取到字段: %v(%T)\n", v, v)
-			}
-			errs = append(errs, e)
-		}
+	if d.err != nil {
+		return tx, d.err
 	}
+	n, err := io.ReadFull(d.r, d.buf[:2])
+	if err == io.EOF { //正好在tx边界结束
+		return tx, io.EOF
+	} else if err != nil {
+		return tx, d.fail("Version", err)
+	}
+	d.off += int64(n)
+	tx.Version = binary.LittleEndian.Uint16(d.buf[:])
 
-	var size int
-	readValue(&tx.Version)
-	readValue(&tx.Typ)
-	readValue(&tx.Timestamp)
-	readValue(&tx.LockUntil)
-	if s.includeAnchor {
-		copy(tx.HashAnchorBytes[:], buffer.Next(int(unsafe.Sizeof(tx.HashAnchorBytes))))
+	var b []byte
+	if b, err = d.read("Typ", 2); err != nil {
+		return tx, err
+	}
+	tx.Typ = binary.LittleEndian.Uint16(b)
+	if b, err = d.read("Timestamp", 4); err != nil {
+		return tx, err
+	}
+	tx.Timestamp = binary.LittleEndian.Uint32(b)
+	if b, err = d.read("LockUntil", 4); err != nil {
+		return tx, err
+	}
+	tx.LockUntil = binary.LittleEndian.Uint32(b)
+	if d.s.includeAnchor {
+		if _, err = d.readFull("HashAnchor", tx.HashAnchorBytes[:]); err != nil {
+			return tx, err
+		}
 	}
 
-	tx.SizeIn, err = readSize(buffer, buffer)
-	if err != nil {
-		return tx, fmt.Errorf("read input size err, %v", err)
+	var limits DecodeLimits
+	if d.s.limits != nil {
+		limits = *d.s.limits
+	}
+	if tx.SizeIn, err = d.readSize("SizeIn", limits.MaxInputs); err != nil {
+		return tx, err
+	}
+	if tx.Input, err = d.readBytes("Input", tx.SizeIn, 33); err != nil {
+		return tx, err
+	}
+	if b, err = d.read("Prefix", 1); err != nil {
+		return tx, err
+	}
+	tx.Prefix = b[0]
+	if _, err = d.readFull("Address", tx.AddressBytes[:]); err != nil {
+		return tx, err
+	}
+	if b, err = d.read("Amount", 8); err != nil {
+		return tx, err
+	}
+	tx.Amount = int64(binary.LittleEndian.Uint64(b))
+	if b, err = d.read("TxFee", 8); err != nil {
+		return tx, err
 	}
+	tx.TxFee = int64(binary.LittleEndian.Uint64(b))
+	if tx.SizeOut, err = d.readSize("SizeOut", limits.MaxDataSize); err != nil {
+		return tx, err
+	}
+	if tx.VchData, err = d.readBytes("VchData", tx.SizeOut, 1); err != nil { //SizeOut 表示vchData字节数
+		return tx, err
+	}
+	if tx.SizeSign, err = d.readSize("SizeSign", limits.MaxSignSize); err != nil {
+		return tx, err
+	}
+	tx.SignBytes, err = d.readBytes("SignBytes", tx.SizeSign, 1)
+	return tx, err
+}
+
+// fail 严格模式返回 *DecodeError, 否则返回普通的error
+func (d *Decoder) fail(field string, err error) error {
+	return d.failAt(field, d.off, err)
+}
 
-	size = 33 * int(tx.SizeIn)
-	tx.Input = make([]byte, size)
-	copy(tx.Input, buffer.Next(size))
+func (d *Decoder) failAt(field string, offset int64, err error) error {
+	if errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF) {
+		err = ErrTruncated
+	}
+	if d.s.limits != nil {
+		return &DecodeError{Field: field, Offset: int(offset), Err: err}
+	}
+	if Debug {
+		log.Printf("[ERR]解析tx数据时无法读取到字段: %s, %v\n", field, err)
+	}
+	return fmt.Errorf("read %s err, %v", field, err)
+}
 
-	readValue(&tx.Prefix)
-	copy(tx.AddressBytes[:], buffer.Next(int(unsafe.Sizeof(tx.AddressBytes))))
-	readValue(&tx.Amount)
-	readValue(&tx.TxFee)
-	tx.SizeOut, err = readSize(buffer, buffer)
+func (d *Decoder) readFull(field string, b []byte) ([]byte, error) {
+	n, err := io.ReadFull(d.r, b)
 	if err != nil {
-		return tx, fmt.Errorf("read output size err, %v", err)
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, d.fail(field, err)
 	}
+	d.off += int64(n)
+	return b, nil
+}
 
-	size = int(tx.SizeOut)
-	tx.VchData = make([]byte, size)
-	copy(tx.VchData, buffer.Next(size)) //考虑逻辑是什么？。。。是不是直接表示字节数，而不是out的笔数
+func (d *Decoder) read(field string, n int) ([]byte, error) {
+	return d.readFull(field, d.buf[:n])
+}
 
-	tx.SizeSign, err = readSize(buffer, buffer)
+func (d *Decoder) readSize(field string, limit uint64) (uint64, error) {
+	start := d.off
+	b, err := d.read(field, 1)
 	if err != nil {
-		return tx, fmt.Errorf("read signature size err, %v", err)
+		return 0, err
+	}
+	size, n := uint64(b[0]), 0
+	switch b[0] {
+	case 0xfd:
+		n = 2
+	case 0xfe:
+		n = 4
+	case 0xff:
+		n = 8
+	}
+	if n > 0 {
+		if b, err = d.read(field, n); err != nil {
+			return 0, err
+		}
+		size = uint64(b[0])
+		for i := 1; i < n; i++ {
+			size |= uint64(b[i]) << (8 * i)
+		}
+	}
+	if d.s.limits == nil {
+		return size, nil
 	}
+	if CompactSizeLen(size) != 1+n {
+		return 0, d.failAt(field, start, ErrNonCanonicalSize)
+	}
+	if size > limit {
+		return 0, d.failAt(field, start, fmt.Errorf("%w: %d > %d", ErrSizeLimit, size, limit))
+	}
+	return size, nil
+}
 
-	size = int(tx.SizeSign)
-	tx.SignBytes = make([]byte, size)
-	copy(tx.SignBytes, buffer.Next(size))
+// readBytes 读取 size*mul 字节，非严格模式下按实际读取到的数据增长缓冲区，避免按照异常的size分配内存
+func (d *Decoder) readBytes(field string, size, mul uint64) ([]byte, error) {
+	if size > math.MaxInt64/mul {
+		return nil, d.fail(field, fmt.Errorf("%w: %d", ErrSizeLimit, size))
+	}
+	n := int64(size * mul)
+	if n == 0 {
+		return []byte{}, nil
+	}
+	if l, ok := d.r.(interface{ Len() int }); ok && int64(l.Len()) < n {
+		return nil, d.fail(field, io.ErrUnexpectedEOF)
+	} else if ok || d.s.limits != nil {
+		return d.readFull(field, make([]byte, n))
+	}
+	var buf bytes.Buffer
+	copied, err := io.CopyN(&buf, d.r, n)
+	if err != nil {
+		return nil, d.fail(field, io.ErrUnexpectedEOF)
+	}
+	d.off += copied
+	return buf.Bytes(), nil
+}
 
-	if len(errs) != 0 {
-		err = fmt.Errorf("some errors when read binary: %v", errs)
+// CompactSizeLen compact size 编码后的字节数
+func CompactSizeLen(size uint64) int {
+	switch {
+	case size < 0xfd:
+		return 1
+	case size <= 0xffff:
+		return 3
+	case size <= 0xffffffff:
+		return 5
+	default:
+		return 9
 	}
-	return tx, err
 }
 
-func writeSize(size uint64, buf *bytes.Buffer) error {
-	switch sz := size; {
-	case sz < 0xFD:
-		return binary.Write(buf, binary.LittleEndian, uint8(size))
-	case sz <= 0xffff:
-		buf.WriteByte(0xfd)
-		return binary.Write(buf, binary.LittleEndian, uint16(size))
-	case sz <= 0xFFFFFFFF:
-		buf.WriteByte(0xfe)
-		return binary.Write(buf, binary.LittleEndian, uint32(size))
-	case sz > 0xFFFFFFFF:
-		buf.WriteByte(0xff)
-		return binary.Write(buf, binary.LittleEndian, size)
+// AppendCompactSize 将 compact size (std::vector 等结构的长度前缀) 追加到dst,
+// ref: https://github.com/bigbangcore/BigBang/wiki/IO-Stream#stdvector-stdmap-stdstring
+func AppendCompactSize(dst []byte, size uint64) []byte {
+	switch CompactSizeLen(size) {
+	case 1:
+		return append(dst, uint8(size))
+	case 3:
+		return append(dst, 0xfd, uint8(size), uint8(size>>8))
+	case 5:
+		return append(dst, 0xfe, uint8(size), uint8(size>>8), uint8(size>>16), uint8(size>>24))
 	default:
-		return fmt.Errorf("should not here, write size, unexpected size: %d", size)
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], size)
+		return append(append(dst, 0xff), b[:]...)
 	}
 }
-func readSize(reader io.ByteReader, buffer io.Reader) (uint64, error) {
-	sizeFlag, err := reader.ReadByte()
-	if err != nil {
-		return 0, fmt.Errorf("unable to read size byte, %v", err)
-	}
-	switch sz := sizeFlag; {
-	case sz < 0xfd:
-		return uint64(sz), nil
-	case sz == 0xfd:
-		var size uint16
-		e := binary.Read(buffer, binary.LittleEndian, &size)
-		return uint64(size), e
-	case sz == 0xfe:
-		var size uint32
-		e := binary.Read(buffer, binary.LittleEndian, &size)
-		return uint64(size), e
-	case sz == 0xff:
-		var size uint64
-		e := binary.Read(buffer, binary.LittleEndian, &size)
-		return size, e
+
+// WriteCompactSize 写入 compact size
+func WriteCompactSize(w io.Writer, size uint64) error {
+	var b [9]byte
+	_, err := w.Write(AppendCompactSize(b[:0], size))
+	return err
+}
+
+// ReadCompactSize 读取 compact size, 不检查是否为最短编码
+func ReadCompactSize(r io.Reader) (uint64, error) {
+	size, _, err := readCompactSize(r)
+	return size, err
+}
+
+// ReadCompactSizeStrict 读取 compact size, 非最短编码时返回 ErrNonCanonicalSize
+func ReadCompactSizeStrict(r io.Reader) (uint64, error) {
+	size, canonical, err := readCompactSize(r)
+	if err == nil && !canonical {
+		err = ErrNonCanonicalSize
+	}
+	return size, err
+}
+
+func readCompactSize(r io.Reader) (size uint64, canonical bool, err error) {
+	var b [9]byte
+	if _, err = io.ReadFull(r, b[:1]); err != nil {
+		return 0, false, fmt.Errorf("unable to read size byte, %w", err)
+	}
+	var n int
+	switch b[0] {
+	case 0xfd:
+		n = 2
+	case 0xfe:
+		n = 4
+	case 0xff:
+		n = 8
+	default:
+		return uint64(b[0]), true, nil
+	}
+	if _, err = io.ReadFull(r, b[1:1+n]); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return 0, false, err
+	}
+	switch n {
+	case 2:
+		size = uint64(binary.LittleEndian.Uint16(b[1:]))
+	case 4:
+		size = uint64(binary.LittleEndian.Uint32(b[1:]))
 	default:
-		return 0, fmt.Errorf("unexpected size flag %d", sz)
+		size = binary.LittleEndian.Uint64(b[1:])
 	}
+	return size, CompactSizeLen(size) == 1+n, nil
 }
@@ -0,0 +1,49 @@
+//go:build go1.18
+// +build go1.18
+
+package gobbc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func fuzzSerializer(f *testing.F, loose, strict Serializer, seeds ...string) {
+	for _, s := range seeds {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(b)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		_, _ = loose.Deserialize(data)
+
+		rtx, err := strict.Deserialize(data)
+		if err != nil {
+			return
+		}
+		// 严格模式下能解析的数据一定是规范编码，重新序列化后应该完全一致
+		b, err := strict.Serialize(rtx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, b) {
+			t.Fatalf("round trip mismatch:\n%x\n%x", data, b)
+		}
+	})
+}
+
+func FuzzBBCSerializer(f *testing.F) {
+	fuzzSerializer(f, BBCSerializer, BBCStrictSerializer,
+		testSignedBBCTx,
+		"01000000dbb7cc5e00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b0000000000182e7a2ae807032941897bd7e01a3221b91cdb63f0a2d64dcad937c9f98e3c55e01017c755b96a15a57a7253d2bf80a1d9c4ca84a9a70da6ab77ab96661fc7b7193cfb0c412000000000010270000000000000000",
+	)
+}
+
+func FuzzMKFSerializer(f *testing.F) {
+	fuzzSerializer(f, MKFSerializer, MKFStrictSerializer,
+		"02000000a61a4e5f0000000001e6c1600226855e8aac1e3d60f76e7b326527e4a72620f0f77af2ae86901a4e5f0002030001e21d6d49931304681ac8ed683d8e90dc8eb6793a875d5361b0bb72bdf9601823000000000030750000000000000000",
+	)
+}
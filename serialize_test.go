@@ -0,0 +1,204 @@
+package gobbc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"math"
+	"testing"
+)
+
+// 签名后的BBC tx, 偏移: Version 0, SizeIn 44, Prefix 78, SizeOut 127, SizeSign 128
+const testSignedBBCTx = "010000008d31d65d0000000069c07b268573a89eb2bf00a895d0ccd557b83af5490e15ca8d41dedc000000000191b5093377f21fc5a76435351504ce5eae7591380cc3502672fb23c2f230d65d00016f757a33cf3b4f83f2b37b2308090f949c6f3870d50ceb3e5aa59b3118c66d7240420f0000000000640000000000000000816f757a33cf3b4f83f2b37b2308090f949c6f3870d50ceb3e5aa59b3118c66d720100815a6d40702a7da0a810de9ba76091cf0f7df0b7b56b7a6ef280c9ff26c14fa178a313c5800bebda19cff9e745a346725838c9b5ecb388797bc04a21bca4a9077dc2140b805b6816ab2a35e692821b7904dcd8bbd52f14c7e5c095b1f20308"
+
+func TestStrictDeserialize(t *testing.T) {
+	w := TW{T: t}
+	raw, err := hex.DecodeString(testSignedBBCTx)
+	w.Nil(err)
+
+	rtx, err := BBCStrictSerializer.Deserialize(raw)
+	w.Nil(err)
+	loose, err := BBCSerializer.Deserialize(raw)
+	w.Nil(err).Equal(loose, rtx)
+
+	mkf, err := hex.DecodeString("02000000a61a4e5f0000000001e6c1600226855e8aac1e3d60f76e7b326527e4a72620f0f77af2ae86901a4e5f0002030001e21d6d49931304681ac8ed683d8e90dc8eb6793a875d5361b0bb72bdf9601823000000000030750000000000000000")
+	w.Nil(err)
+	_, err = MKFStrictSerializer.Deserialize(mkf)
+	w.Nil(err)
+
+	//修改 DefaultDecodeLimits 不影响已导出的严格模式序列化器
+	saved := DefaultDecodeLimits
+	DefaultDecodeLimits = DecodeLimits{}
+	_, err = BBCStrictSerializer.Deserialize(raw)
+	DefaultDecodeLimits = saved
+	w.Nil(err)
+
+	small, err := NewStrictSerializer(BBCSerializer, DecodeLimits{MaxInputs: 1, MaxDataSize: 0, MaxSignSize: 64})
+	w.Nil(err)
+	_, err = NewStrictSerializer(nil, DefaultDecodeLimits)
+	w.True(err != nil)
+
+	replace := func(off int, v ...byte) []byte {
+		b := append([]byte{}, raw...)
+		return append(b[:off], append(v, b[off+len(v):]...)...)
+	}
+	for _, tt := range []struct {
+		name       string
+		serializer Serializer
+		data       []byte
+		field      string
+		offset     int
+		err        error
+	}{
+		{"truncated", BBCStrictSerializer, raw[:len(raw)-1], "SignBytes", 129, ErrTruncated},
+		{"truncated header", BBCStrictSerializer, raw[:3], "Typ", 2, ErrTruncated},
+		{"trailing", BBCStrictSerializer, append(append([]byte{}, raw...), 0), "EOF", len(raw), ErrTrailingData},
+		{"sign limit", small, raw, "SizeSign", 128, ErrSizeLimit},
+		{"non canonical", BBCStrictSerializer, append(replace(44, 0xfd, 1, 0), raw[45:]...), "SizeIn", 44, ErrNonCanonicalSize},
+		{"huge size", BBCStrictSerializer, replace(128, 0xff, 0, 0, 0, 0, 1, 0, 0, 0), "SizeSign", 128, ErrSizeLimit},
+		{"mkf trailing", MKFStrictSerializer, append(mkf, 0), "EOF", len(mkf), ErrTrailingData},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := TW{T: t}
+			_, err := tt.serializer.Deserialize(tt.data)
+			var de *DecodeError
+			w.True(errors.As(err, &de), err).
+				True(errors.Is(err, tt.err), err).
+				Equal(tt.field, de.Field)
+			if tt.offset > 0 {
+				w.Equal(tt.offset, de.Offset)
+			}
+		})
+	}
+}
+
+func TestEncoderDecoder(t *testing.T) {
+	w := TW{T: t}
+	var txs []RawTransaction
+	for _, d := range []string{testSignedBBCTx, "01000000dbb7cc5e00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b0000000000182e7a2ae807032941897bd7e01a3221b91cdb63f0a2d64dcad937c9f98e3c55e01017c755b96a15a57a7253d2bf80a1d9c4ca84a9a70da6ab77ab96661fc7b7193cfb0c412000000000010270000000000000000"} {
+		tx, err := DecodeRawTransaction(BBCSerializer, d, true)
+		w.Nil(err)
+		txs = append(txs, tx.RawTransaction)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	enc := NewEncoder(buf, BBCSerializer)
+	for _, tx := range txs {
+		w.Nil(enc.Encode(tx))
+	}
+	total := buf.Len()
+
+	dec := NewDecoder(buf, BBCStrictSerializer)
+	for _, tx := range txs {
+		got, err := dec.Decode()
+		w.Nil(err).Equal(tx, got)
+	}
+	_, err := dec.Decode()
+	w.Equal(io.EOF, err).Equal(int64(total), dec.Offset())
+
+	// 截断在tx中间
+	dec = NewDecoder(bytes.NewReader([]byte{1, 0, 0}), BBCStrictSerializer)
+	_, err = dec.Decode()
+	w.True(errors.Is(err, ErrTruncated), err)
+
+	_, err = NewDecoder(buf, nil).Decode()
+	w.True(err != nil)
+	w.True(NewEncoder(buf, nil).Encode(RawTransaction{}) != nil)
+}
+
+func TestCompactSize(t *testing.T) {
+	w := TW{T: t}
+	for _, size := range []uint64{0, 1, 0xfc, 0xfd, 0xffff, 0x10000, 0xffffffff, 0x100000000, math.MaxUint64} {
+		buf := bytes.NewBuffer(nil)
+		w.Nil(WriteCompactSize(buf, size))
+		w.Equal(CompactSizeLen(size), buf.Len())
+		got, err := ReadCompactSizeStrict(buf)
+		w.Nil(err).Equal(size, got)
+	}
+
+	_, err := ReadCompactSizeStrict(bytes.NewReader([]byte{0xfd, 0x01, 0x00}))
+	w.Equal(ErrNonCanonicalSize, err)
+	size, err := ReadCompactSize(bytes.NewReader([]byte{0xfd, 0x01, 0x00}))
+	w.Nil(err).Equal(uint64(1), size)
+	_, err = ReadCompactSize(bytes.NewReader([]byte{0xfe, 0x01}))
+	w.True(err != nil)
+}
+
+// reflectSerialize 基于 binary.Write 的序列化实现，仅用于对比 benchmark
+func reflectSerialize(rtx RawTransaction) []byte {
+	buf := bytes.NewBuffer(nil)
+	write := func(v interface{}) { _ = binary.Write(buf, binary.LittleEndian, v) }
+	write(rtx.Version)
+	write(rtx.Typ)
+	write(rtx.Timestamp)
+	write(rtx.LockUntil)
+	buf.Write(rtx.HashAnchorBytes[:])
+	_ = WriteCompactSize(buf, rtx.SizeIn)
+	buf.Write(rtx.Input)
+	write(rtx.Prefix)
+	buf.Write(rtx.AddressBytes[:])
+	write(rtx.Amount)
+	write(rtx.TxFee)
+	_ = WriteCompactSize(buf, rtx.SizeOut)
+	buf.Write(rtx.VchData)
+	_ = WriteCompactSize(buf, rtx.SizeSign)
+	buf.Write(rtx.SignBytes)
+	return buf.Bytes()
+}
+
+func benchmarkTx(b *testing.B) RawTransaction {
+	raw, _ := hex.DecodeString(testSignedBBCTx)
+	rtx, err := BBCSerializer.Deserialize(raw)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if !bytes.Equal(raw, reflectSerialize(rtx)) {
+		b.Fatal("reflectSerialize mismatch")
+	}
+	return rtx
+}
+
+func BenchmarkSerializeReflect(b *testing.B) {
+	rtx := benchmarkTx(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		reflectSerialize(rtx)
+	}
+}
+
+func BenchmarkSerialize(b *testing.B) {
+	rtx := benchmarkTx(b)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = BBCSerializer.Serialize(rtx)
+	}
+}
+
+func BenchmarkAppendSerialize(b *testing.B) {
+	rtx := benchmarkTx(b)
+	buf := make([]byte, 0, 512)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		buf, _ = rtx.AppendSerialize(buf[:0], BBCSerializer)
+	}
+}
+
+func BenchmarkEncoder(b *testing.B) {
+	rtx := benchmarkTx(b)
+	enc := NewEncoder(ioutil.Discard, BBCSerializer)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = enc.Encode(rtx)
+	}
+}
+
+func BenchmarkDeserialize(b *testing.B) {
+	raw, _ := hex.DecodeString(testSignedBBCTx)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_, _ = BBCSerializer.Deserialize(raw)
+	}
+}
@@ -0,0 +1,247 @@
+package gobbc
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"math/bits"
+)
+
+// SignatureKind 签名数据中模版数据之后的签名部分的格式
+type SignatureKind uint8
+
+// 签名格式
+const (
+	SignaturePlain    SignatureKind = iota //64字节ed25519签名
+	SignatureMultisig                      //多签: 签名者位图 + n个64字节签名
+	SignatureExchange                      //exchange模版: vsm + vss + 签名(均带长度)
+)
+
+func (k SignatureKind) String() string {
+	switch k {
+	case SignaturePlain:
+		return "plain"
+	case SignatureMultisig:
+		return "multisig"
+	case SignatureExchange:
+		return "exchange"
+	}
+	return fmt.Sprintf("SignatureKind(%d)", uint8(k))
+}
+
+// Signature 解析后的签名部分
+type Signature struct {
+	Kind     SignatureKind
+	Bitmap   []byte   //多签: 已签名公钥(按小端排序后)的位图
+	Sigs     [][]byte //各64字节签名, 多签时按公钥顺序
+	VSM, VSS []byte   //exchange: 双方对模版的签名
+}
+
+// ParseSignature 根据模版数据列表的最后一项解析签名部分:
+// 多签模版为多签格式, exchange模版为exchange格式, 其他为64字节签名
+func ParseSignature(templates TemplateList, sig []byte) (*Signature, error) {
+	var last Template
+	if len(templates) > 0 {
+		last = templates[len(templates)-1]
+	}
+	r := dataReader{b: sig}
+	s := Signature{Kind: SignaturePlain}
+	switch last.Type {
+	case TemplateTypeWeighted, TemplateTypeMultisig:
+		info, err := ParseMultisigTemplateHex(last.Hex())
+		if err != nil {
+			return nil, err
+		}
+		s.Kind = SignatureMultisig
+		s.Bitmap = r.next("bitmap", (int(info.N)-1)/8+1)
+		if r.err != nil {
+			return nil, r.err
+		}
+		count := 0
+		for i, x := range s.Bitmap {
+			if i == len(s.Bitmap)-1 && int(info.N)%8 != 0 && x>>(uint(info.N)%8) != 0 {
+				return nil, errors.New("multisig bitmap out of range")
+			}
+			count += bits.OnesCount8(x)
+		}
+		if count == 0 {
+			return nil, errors.New("empty multisig bitmap")
+		}
+		for i := 0; i < count; i++ {
+			s.Sigs = append(s.Sigs, r.next("sig", ed25519.SignatureSize))
+		}
+	case TemplateTypeExchange:
+		s.Kind = SignatureExchange
+		s.VSM, s.VSS = r.varBytes("vsm"), r.varBytes("vss")
+		sig := r.varBytes("sig")
+		if r.err == nil && len(sig) != ed25519.SignatureSize {
+			return nil, errors.New("invalid exchange signature length")
+		}
+		s.Sigs = [][]byte{sig}
+	default:
+		s.Sigs = [][]byte{r.next("sig", ed25519.SignatureSize)}
+	}
+	if err := r.close(); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// signDataTypes 签名数据中模版类型未知时依次尝试的类型
+var signDataTypes = []TemplateType{
+	TemplateTypeMultisig, TemplateTypeDelegate, TemplateTypeVote, TemplateTypeFork,
+	TemplateTypeExchange, TemplateTypePayment, templateDexorder, TemplateTypeProof, TemplateTypeWeighted,
+}
+
+// ErrSignDataAmbiguous 签名数据中的模版数据可以按多个模版类型完整解析(如delegate与proof的数据格式相同),
+// 且都不是 hints 中的地址
+var ErrSignDataAmbiguous = errors.New("ambiguous template data in sign data")
+
+// errSignDataMismatch 签名数据不符合当前尝试的模版类型
+var errSignDataMismatch = errors.New("unable to parse sign data")
+
+// templateDataLens 按模版类型的编码格式, b开头可能的模版数据长度
+func templateDataLens(typ TemplateType, b []byte) []int {
+	r := dataReader{b: b}
+	switch typ {
+	case TemplateTypeWeighted, TemplateTypeMultisig:
+		r.uint8("m")
+		n := r.uint64("n")
+		if n == 0 || n > 255 {
+			return nil
+		}
+		r.next("keys", int(n)*33)
+	case TemplateTypeFork, TemplateTypeProof, TemplateTypeDelegate:
+		r.next("data", destinationLen+uint256Len)
+	case TemplateTypeVote:
+		r.next("data", 2*destinationLen)
+	case TemplateTypeExchange:
+		r.next("data", 2*destinationLen+4+4+2*uint256Len)
+	case TemplateTypePayment:
+		r.next("data", 2*destinationLen+4+8+8+4)
+	case templateDexorder:
+		r.destination("seller_address")
+		r.varBytes("coinpair")
+		r.uint64("price")
+		r.uint32("fee")
+		r.varBytes("recv_address")
+		r.uint32("valid_height")
+		r.destination("match_address")
+		r.varBytes("deal_address")
+		if r.err == nil && len(b)-r.off >= 4 { //timestamp 可选
+			return []int{r.off + 4, r.off}
+		}
+	default:
+		return nil
+	}
+	if r.err != nil {
+		return nil
+	}
+	return []int{r.off}
+}
+
+// validateTemplate 按模版类型完整解析模版数据
+func validateTemplate(t Template) error {
+	var err error
+	switch t.Type {
+	case TemplateTypeWeighted, TemplateTypeMultisig:
+		_, err = ParseMultisigTemplateHex(t.Hex())
+	case TemplateTypeExchange:
+		_, err = ParseExchangeTemplateHex(t.Hex())
+	case TemplateTypePayment:
+		_, err = ParsePaymentTemplateHex(t.Hex())
+	default:
+		_, _, err = templateOwner(t.Hex())
+	}
+	return err
+}
+
+// ParseSignData 将签名数据(SignBytes)拆分为模版数据列表和签名部分, 签名部分的格式使用 ParseSignature 解析.
+// 签名数据中的模版数据不含类型, 按以下规则尝试各模版类型的编码:
+// - 模版的owner为模版地址时, 下一项为owner的模版数据(类型由地址确定, 且需与地址一致)
+// - 第一项为投票模版(转账地址为投票模版)时, 下一项可以是任意模版(from的模版数据)
+// - 最后的签名部分需符合最后一项模版的签名格式
+// 部分模版数据格式相同(如delegate与proof), 按多个类型都能完整解析时, 使用 hints(如转账地址、from地址)中的地址,
+// 没有匹配的 hints 时返回 ErrSignDataAmbiguous, 不做猜测
+func ParseSignData(signBytes []byte, hints ...string) (templates TemplateList, sig []byte, err error) {
+	hinted := map[string]bool{}
+	for _, h := range hints {
+		hinted[h] = true
+	}
+	return walkSignData(signBytes, nil, hinted)
+}
+
+// SignData 解析签名数据, 转账地址作为 hints
+func (rtx *RawTransaction) SignData() (TemplateList, []byte, error) {
+	to := CDestination{Prefix: rtx.Prefix, Data: rtx.AddressBytes}
+	return ParseSignData(rtx.SignBytes, to.String())
+}
+
+// walkSignData 解析成功或 ErrSignDataAmbiguous 时结束, 当前路径不匹配时返回 errSignDataMismatch
+func walkSignData(b []byte, l TemplateList, hinted map[string]bool) (TemplateList, []byte, error) {
+	free := len(l) == 0 //下一项可以是任意模版
+	if len(l) > 0 {
+		prev := l[len(l)-1]
+		owner, ok, _ := templateOwner(prev.Hex())
+		if ok && owner.IsTemplate() && len(l) <= maxTemplateDepth {
+			typ := owner.TemplateType()
+			for _, n := range templateDataLens(typ, b) {
+				t := Template{Type: typ, Data: b[:n]}
+				if t.Address() != owner.String() || validateTemplate(t) != nil {
+					continue
+				}
+				if ret, sig, err := walkSignData(b[n:], append(l[:len(l):len(l)], t), hinted); err != errSignDataMismatch {
+					return ret, sig, err
+				}
+			}
+		}
+		free = len(l) == 1 && prev.Type == TemplateTypeVote
+		if ok && owner.IsTemplate() && !free {
+			return nil, nil, errSignDataMismatch
+		}
+	}
+	if _, err := ParseSignature(l, b); err == nil {
+		return l, b, nil
+	}
+	if !free {
+		return nil, nil, errSignDataMismatch
+	}
+
+	var candidates []Template
+	for _, typ := range signDataTypes {
+		for _, n := range templateDataLens(typ, b) {
+			t := Template{Type: typ, Data: b[:n]}
+			if validateTemplate(t) != nil {
+				continue
+			}
+			if hinted[t.Address()] { //已知地址优先
+				candidates = append([]Template{t}, candidates...)
+			} else {
+				candidates = append(candidates, t)
+			}
+		}
+	}
+	var found TemplateList
+	var foundSig []byte
+	for _, t := range candidates {
+		if len(l) > 0 && l[0].Address() == t.Address() { //投票赎回时from与转账地址为同一投票模版, 只出现一次
+			continue
+		}
+		ret, sig, err := walkSignData(b[len(t.Data):], append(l[:len(l):len(l)], t), hinted)
+		if err == errSignDataMismatch {
+			continue
+		}
+		if err != nil || hinted[t.Address()] {
+			return ret, sig, err
+		}
+		if found != nil {
+			return nil, nil, fmt.Errorf("%w: %s(%s) or %s(%s)", ErrSignDataAmbiguous,
+				found[len(l)].Address(), found[len(l)].Type, t.Address(), t.Type)
+		}
+		found, foundSig = ret, sig
+	}
+	if found == nil {
+		return nil, nil, errSignDataMismatch
+	}
+	return found, foundSig, nil
+}
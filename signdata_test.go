@@ -0,0 +1,85 @@
+package gobbc
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseSignData(t *testing.T) {
+	w := TW{T: t}
+	var keys []AddrKeyPair
+	for i := 0; i < 4; i++ {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys = append(keys, k)
+	}
+	multisigTpl := testMultisigTplHex(t, 2, keys[0].Pubk, keys[1].Pubk, keys[2].Pubk)
+	multisigAddr, err := TemplateAddress(multisigTpl)
+	w.Nil(err)
+	multisigDest, err := NewCDestinationFromAddress(multisigAddr)
+	w.Nil(err)
+	delegateAddr, delegateTpl, err := CreateTemplateDataDelegate(keys[2].Pubk, multisigDest)
+	w.Nil(err)
+	delegateDest, err := NewCDestinationFromAddress(delegateAddr)
+	w.Nil(err)
+	voterDest, err := NewCDestinationFromAddress(keys[3].Addr)
+	w.Nil(err)
+	voteAddr, voteTpl, err := CreateTemplateDataVote(VoteTpl{Delegate: delegateDest, Voter: voterDest})
+	w.Nil(err)
+	proofAddr, proofTpl, err := CreateTemplateDataProof(keys[2].Pubk, voterDest)
+	w.Nil(err)
+	resolver, err := NewMemoryTemplateResolver(multisigTpl, delegateTpl, voteTpl, proofTpl)
+	w.Nil(err)
+
+	// multisig与weighted, delegate与proof的数据格式相同, 没有from地址作为hints时无法确定
+	for _, tt := range []struct {
+		name, from, to string
+		expected       []string
+		privks         []string
+		kind           SignatureKind
+		sigs           int
+		ambiguous      bool
+	}{
+		{"pubkey", keys[3].Addr, keys[0].Addr, nil, []string{keys[3].Privk}, SignaturePlain, 1, false},
+		{"vote", keys[3].Addr, voteAddr, []string{voteTpl}, []string{keys[3].Privk}, SignaturePlain, 1, false},
+		{"redeem vote", voteAddr, keys[3].Addr, []string{voteTpl}, []string{keys[3].Privk}, SignaturePlain, 1, false},
+		{"multisig", multisigAddr, keys[3].Addr, []string{multisigTpl}, []string{keys[0].Privk}, SignatureMultisig, 1, true},
+		{"multisig delegate", delegateAddr, keys[3].Addr, []string{delegateTpl, multisigTpl}, []string{keys[0].Privk, keys[2].Privk}, SignatureMultisig, 2, true},
+		{"vote from multisig delegate", delegateAddr, voteAddr, []string{voteTpl, delegateTpl, multisigTpl}, []string{keys[1].Privk, keys[0].Privk}, SignatureMultisig, 2, true},
+		{"proof", proofAddr, keys[0].Addr, []string{proofTpl}, []string{keys[3].Privk}, SignaturePlain, 1, true},
+	} {
+		rtx, err := NewTXBuilder().
+			SetAnchor(BBCMainnet.GenesisAnchor).
+			SetTimestamp(1590474715).
+			AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+			SetAddress(tt.to).
+			SetAmount(1).SetFee(0.01).
+			Build()
+		w.Nil(err)
+		w.Nil(rtx.SignAuto(BBCSerializer, tt.from, resolver, tt.privks...), tt.name)
+
+		tpls, sig, err := rtx.SignData()
+		if tt.ambiguous {
+			w.True(errors.Is(err, ErrSignDataAmbiguous), tt.name, err)
+			tpls, sig, err = ParseSignData(rtx.SignBytes, tt.to, tt.from)
+		}
+		w.Nil(err, tt.name).Equal(strings.Join(tt.expected, ","), tpls.String(), tt.name)
+		s, err := ParseSignature(tpls, sig)
+		w.Nil(err, tt.name).Equal(tt.kind, s.Kind, tt.name).Equal(tt.sigs, len(s.Sigs), tt.name)
+
+		tpls2, sig2, err := ParseSignData(rtx.SignBytes, tt.from)
+		w.Nil(err, tt.name).Equal(tpls, tpls2, tt.name).Equal(sig, sig2, tt.name)
+	}
+
+	_, _, err = ParseSignData(make([]byte, ed25519.SignatureSize-1))
+	w.True(err != nil, "short signature")
+	noOwner := mustHexDecode(t, delegateTpl)[2:]
+	noOwner[0] = 0 //避免被解析为redeem为公钥的分支模版
+	_, _, err = ParseSignData(append(noOwner, make([]byte, ed25519.SignatureSize)...))
+	w.True(err != nil, "owner template missing")
+	_, err = ParseSignature(TemplateList{{Type: TemplateTypeMultisig, Data: mustHexDecode(t, multisigTpl)[2:]}}, make([]byte, 1+64))
+	w.True(err != nil, "empty bitmap")
+	w.Equal("multisig", SignatureMultisig.String())
+}
@@ -0,0 +1,325 @@
+package gobbc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/blake2b"
+)
+
+// 模版数据中各字段的长度
+const (
+	destinationLen = 33 //CDestination: prefix + 32字节
+	uint256Len     = 32
+)
+
+// Bytes CDestination 序列化(prefix + data)
+func (a CDestination) Bytes() []byte {
+	return append([]byte{a.Prefix}, a.Data[:]...)
+}
+
+// IsTemplate 是否为模版地址
+func (a CDestination) IsTemplate() bool { return a.Prefix == PrefixTemplate }
+
+// TemplateType 模版地址的模版类型，非模版地址返回 TemplateTypeMin
+func (a CDestination) TemplateType() TemplateType {
+	if !a.IsTemplate() {
+		return TemplateTypeMin
+	}
+	return TemplateType(binary.LittleEndian.Uint16(a.Data[:2]))
+}
+
+func parseDestination(b []byte) (CDestination, error) {
+	if len(b) < destinationLen {
+		return CDestination{}, errors.New("invalid destination len")
+	}
+	dest := CDestination{Prefix: b[0]}
+	if dest.Prefix != PrefixPubk && dest.Prefix != PrefixTemplate {
+		return dest, fmt.Errorf("invalid destination prefix %d", dest.Prefix)
+	}
+	copy(dest.Data[:], b[1:destinationLen])
+	return dest, nil
+}
+
+// templateDestination 模版地址: [2字节类型(little endian)][blake2b(模版数据(不含类型))[:30]]
+func templateDestination(typ TemplateType, data []byte) CDestination {
+	hash := blake2b.Sum256(data)
+	dest := CDestination{Prefix: PrefixTemplate}
+	binary.LittleEndian.PutUint16(dest.Data[:2], uint16(typ))
+	copy(dest.Data[2:], hash[:len(hash)-2])
+	return dest
+}
+
+// encodeTemplate 返回 模版地址, 模版数据hex(含2字节类型)
+func encodeTemplate(typ TemplateType, data []byte) (string, string) {
+	b := make([]byte, 2, 2+len(data))
+	binary.LittleEndian.PutUint16(b, uint16(typ))
+	return templateDestination(typ, data).String(), hex.EncodeToString(append(b, data...))
+}
+
+// decodeTemplateHex 解析模版数据hex, 返回类型和(不含类型的)数据
+func decodeTemplateHex(tplHex string) (TemplateType, []byte, error) {
+	b, err := hex.DecodeString(tplHex)
+	if err != nil {
+		return 0, nil, fmt.Errorf("invalid template hex, %v", err)
+	}
+	if len(b) < 2 {
+		return 0, nil, errors.New("template data too short")
+	}
+	return TemplateType(binary.LittleEndian.Uint16(b[:2])), b[2:], nil
+}
+
+// TemplateAddress 根据模版数据(通过rpc validateaddress 获取的templatedata.hex, 含2字节类型)计算模版地址
+func TemplateAddress(tplHex string) (string, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return "", err
+	}
+	return templateDestination(typ, data).String(), nil
+}
+
+// DelegateTemplate dpos delegate 模版
+// |---32---|---33---|
+// |delegate| owner  |
+type DelegateTemplate struct {
+	Delegate []byte       //delegate 公钥(出块、注册时签名使用)
+	Owner    CDestination //资金所有者，从模版转出时由owner签名
+}
+
+// CreateTemplateDataDelegate 创建delegate模版, delegatePubk: 公钥hex(与MakeKeyPair的Pubk格式相同), 返回 模版地址, 模版数据hex
+func CreateTemplateDataDelegate(delegatePubk string, owner CDestination) (string, string, error) {
+	pubk, err := ParsePublicKeyHex(delegatePubk)
+	if err != nil {
+		return "", "", err
+	}
+	addr, tplHex := encodeTemplate(TemplateTypeDelegate, append(pubk, owner.Bytes()...))
+	return addr, tplHex, nil
+}
+
+// ParseDelegateTemplateHex 解析delegate模版数据
+func ParseDelegateTemplateHex(tplHex string) (*DelegateTemplate, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypeDelegate {
+		return nil, fmt.Errorf("not a delegate template: %s", typ)
+	}
+	if len(data) != uint256Len+destinationLen {
+		return nil, fmt.Errorf("invalid delegate template len %d", len(data))
+	}
+	owner, err := parseDestination(data[uint256Len:])
+	if err != nil {
+		return nil, err
+	}
+	return &DelegateTemplate{Delegate: data[:uint256Len], Owner: owner}, nil
+}
+
+// 按照core CODataStream格式(little endian, vector/string/map长度为uint64)编码模版、profile等数据
+func appendUint16(dst []byte, v uint16) []byte {
+	var b [2]byte
+	binary.LittleEndian.PutUint16(b[:], v)
+	return append(dst, b[:]...)
+}
+
+func appendUint32(dst []byte, v uint32) []byte {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	return append(dst, b[:]...)
+}
+
+func appendUint64(dst []byte, v uint64) []byte {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	return append(dst, b[:]...)
+}
+
+func appendVarBytes(dst, b []byte) []byte {
+	return append(appendUint64(dst, uint64(len(b))), b...)
+}
+
+// dataReader 按照CODataStream格式读取数据，出错后的读取均返回零值，通过 err 获取首个错误
+type dataReader struct {
+	b   []byte
+	off int
+	err error
+}
+
+func (r *dataReader) next(field string, n int) []byte {
+	if r.err != nil {
+		return nil
+	}
+	if n < 0 || len(r.b)-r.off < n {
+		r.err = fmt.Errorf("read %s err, %v", field, ErrTruncated)
+		return nil
+	}
+	b := r.b[r.off : r.off+n]
+	r.off += n
+	return b
+}
+
+func (r *dataReader) uint8(field string) uint8 {
+	if b := r.next(field, 1); b != nil {
+		return b[0]
+	}
+	return 0
+}
+
+func (r *dataReader) uint16(field string) uint16 {
+	if b := r.next(field, 2); b != nil {
+		return binary.LittleEndian.Uint16(b)
+	}
+	return 0
+}
+
+func (r *dataReader) uint32(field string) uint32 {
+	if b := r.next(field, 4); b != nil {
+		return binary.LittleEndian.Uint32(b)
+	}
+	return 0
+}
+
+func (r *dataReader) uint64(field string) uint64 {
+	if b := r.next(field, 8); b != nil {
+		return binary.LittleEndian.Uint64(b)
+	}
+	return 0
+}
+
+func (r *dataReader) hash(field string) (h [32]byte) {
+	copy(h[:], r.next(field, 32))
+	return
+}
+
+func (r *dataReader) varBytes(field string) []byte {
+	n := r.uint64(field)
+	if r.err == nil && n > uint64(len(r.b)-r.off) {
+		r.err = fmt.Errorf("read %s err, %v", field, ErrTruncated)
+	}
+	return r.next(field, int(n))
+}
+
+func (r *dataReader) destination(field string) CDestination {
+	b := r.next(field, destinationLen)
+	if b == nil {
+		return CDestination{}
+	}
+	dest, err := parseDestination(b)
+	if err != nil {
+		r.err = fmt.Errorf("read %s err, %v", field, err)
+	}
+	return dest
+}
+
+// close 返回读取过程中的错误，数据未读完时返回 ErrTrailingData
+func (r *dataReader) close() error {
+	if r.err == nil && r.off != len(r.b) {
+		return fmt.Errorf("%v: %d bytes", ErrTrailingData, len(r.b)-r.off)
+	}
+	return r.err
+}
+
+// ForkTemplate 分支模版，创建分支时质押的币转入该模版地址
+// |---33---|---32---|
+// | redeem |  fork  |
+type ForkTemplate struct {
+	Redeem CDestination //质押赎回地址
+	ForkID string       //分支id(hex)
+}
+
+// CreateTemplateDataFork 创建分支模版, forkID: 分支origin块hash, 返回 模版地址, 模版数据hex
+func CreateTemplateDataFork(redeem CDestination, forkID string) (string, string, error) {
+	id, err := decodeHash(forkID)
+	if err != nil {
+		return "", "", err
+	}
+	addr, tplHex := encodeTemplate(TemplateTypeFork, append(redeem.Bytes(), id[:]...))
+	return addr, tplHex, nil
+}
+
+// ParseForkTemplateHex 解析分支模版数据
+func ParseForkTemplateHex(tplHex string) (*ForkTemplate, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypeFork {
+		return nil, fmt.Errorf("not a fork template: %s", typ)
+	}
+	r := dataReader{b: data}
+	tpl := ForkTemplate{Redeem: r.destination("redeem")}
+	id := r.hash("fork")
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	tpl.ForkID = CopyReverseThenEncodeHex(id[:])
+	return &tpl, nil
+}
+
+// ProofTemplate pow挖矿模版
+// |---32---|---33---|
+// |  mint  | spent  |
+type ProofTemplate struct {
+	Mint  []byte       //挖矿(出块签名)公钥
+	Spent CDestination //资金所有者，从模版转出时由该地址签名
+}
+
+// CreateTemplateDataProof 创建pow挖矿模版, mintPubk: 出块签名公钥hex, owner: 收益所有者, 返回 模版地址, 模版数据hex,
+// 从该模版转出时使用owner私钥签名: rtx.SignWithPrivateKey(serializer, proofTpl, ownerPrivk)
+// (owner为多签地址时模版数据为 proofTpl,multisigTpl)
+func CreateTemplateDataProof(mintPubk string, owner CDestination) (string, string, error) {
+	pubk, err := ParsePublicKeyHex(mintPubk)
+	if err != nil {
+		return "", "", err
+	}
+	addr, tplHex := encodeTemplate(TemplateTypeProof, append(pubk, owner.Bytes()...))
+	return addr, tplHex, nil
+}
+
+// ParseProofTemplateHex 解析pow挖矿模版数据
+func ParseProofTemplateHex(tplHex string) (*ProofTemplate, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypeProof {
+		return nil, fmt.Errorf("not a proof template: %s", typ)
+	}
+	r := dataReader{b: data}
+	tpl := ProofTemplate{Mint: r.next("mint", uint256Len), Spent: r.destination("spent")}
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
+
+// CreateTemplateDataVote 创建dpos投票模版
+// |---33---|---33---|
+// |delegate| voter  |
+// 返回 模版地址, 模版数据hex
+func CreateTemplateDataVote(tpl VoteTpl) (string, string, error) {
+	if tpl.Delegate.TemplateType() != TemplateTypeDelegate {
+		return "", "", errors.New("vote delegate should be delegate template address")
+	}
+	addr, tplHex := encodeTemplate(TemplateTypeVote, append(tpl.Delegate.Bytes(), tpl.Voter.Bytes()...))
+	return addr, tplHex, nil
+}
+
+// ParseVoteTemplateHex 解析dpos投票模版数据
+func ParseVoteTemplateHex(tplHex string) (*VoteTpl, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return nil, err
+	}
+	if typ != TemplateTypeVote {
+		return nil, fmt.Errorf("not a vote template: %s", typ)
+	}
+	r := dataReader{b: data}
+	tpl := VoteTpl{Delegate: r.destination("delegate"), Voter: r.destination("voter")}
+	if err = r.close(); err != nil {
+		return nil, err
+	}
+	return &tpl, nil
+}
@@ -0,0 +1,49 @@
+package gobbc
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"strings"
+	"testing"
+)
+
+func TestProofTemplate(t *testing.T) {
+	w := TW{T: t}
+	mint, err := MakeKeyPair()
+	w.Nil(err)
+	owner, err := MakeKeyPair()
+	w.Nil(err)
+	ownerDest, err := NewCDestinationFromAddress(owner.Addr)
+	w.Nil(err)
+
+	addr, tplHex, err := CreateTemplateDataProof(mint.Pubk, ownerDest)
+	w.Nil(err).True(strings.HasPrefix(addr, "20g0"), addr) //模版类型4
+	w.Equal(TemplateTypeProof, GetTemplateType(tplHex))
+	tplAddr, err := TemplateAddress(tplHex)
+	w.Nil(err).Equal(addr, tplAddr)
+
+	tpl, err := ParseProofTemplateHex(tplHex)
+	w.Nil(err).Equal(mint.Pubk, CopyReverseThenEncodeHex(tpl.Mint)).Equal(owner.Addr, tpl.Spent.String())
+	_, err = ParseProofTemplateHex(tplHex[:len(tplHex)-2])
+	w.True(err != nil)
+	_, _, err = CreateTemplateDataProof("xx", ownerDest)
+	w.True(err != nil)
+
+	// 从挖矿模版转出, owner签名
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+		SetAddress(owner.Addr).
+		SetAmount(15).SetFee(0.01).
+		Build()
+	w.Nil(err)
+	w.Nil(rtx.SignWithPrivateKey(BBCMainnet, tplHex, owner.Privk))
+	tplData := mustHexDecode(t, tplHex)[2:]
+	w.True(bytes.HasPrefix(rtx.SignBytes, tplData))
+	hash, err := rtx.TxHash(BBCMainnet)
+	w.Nil(err)
+	pubk, err := ParsePublicKeyHex(owner.Pubk)
+	w.Nil(err)
+	w.True(ed25519.Verify(pubk, hash[:], rtx.SignBytes[len(tplData):]))
+}
@@ -0,0 +1,193 @@
+package gobbc
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Template 模版数据
+type Template struct {
+	Type TemplateType
+	Data []byte //不含2字节类型
+}
+
+// ParseTemplate 解析模版数据hex(含2字节类型)
+func ParseTemplate(tplHex string) (Template, error) {
+	typ, data, err := decodeTemplateHex(tplHex)
+	if err != nil {
+		return Template{}, err
+	}
+	return Template{Type: typ, Data: data}, nil
+}
+
+// Hex 模版数据hex(含2字节类型), 与rpc validateaddress 返回的 templatedata.hex 格式相同
+func (t Template) Hex() string {
+	b := make([]byte, 2, 2+len(t.Data))
+	binary.LittleEndian.PutUint16(b, uint16(t.Type))
+	return hex.EncodeToString(append(b, t.Data...))
+}
+
+// String .
+func (t Template) String() string { return t.Hex() }
+
+// Address 模版地址
+func (t Template) Address() string { return templateDestination(t.Type, t.Data).String() }
+
+// MarshalText .
+func (t Template) MarshalText() ([]byte, error) { return []byte(t.Hex()), nil }
+
+// UnmarshalText .
+func (t *Template) UnmarshalText(b []byte) error {
+	x, err := ParseTemplate(string(b))
+	if err != nil {
+		return err
+	}
+	*t = x
+	return nil
+}
+
+// TemplateList 签名时使用的模版数据列表, 文本格式为使用,分隔的模版数据hex(即 SignWithPrivateKey 的 templateDataList 参数),
+// json格式为模版数据hex数组
+type TemplateList []Template
+
+// ParseTemplateList 解析使用,分隔的模版数据列表, 忽略空项
+func ParseTemplateList(s string) (TemplateList, error) {
+	var l TemplateList
+	for _, tpl := range strings.Split(s, TemplateDataSpliter) {
+		if len(tpl) == 0 {
+			continue
+		}
+		t, err := ParseTemplate(tpl)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode template data: %v", err)
+		}
+		l = append(l, t)
+	}
+	return l, nil
+}
+
+// String 使用,分隔的模版数据hex
+func (l TemplateList) String() string {
+	list := make([]string, len(l))
+	for i, t := range l {
+		list[i] = t.Hex()
+	}
+	return strings.Join(list, TemplateDataSpliter)
+}
+
+// MarshalText .
+func (l TemplateList) MarshalText() ([]byte, error) { return []byte(l.String()), nil }
+
+// UnmarshalText .
+func (l *TemplateList) UnmarshalText(b []byte) error {
+	x, err := ParseTemplateList(string(b))
+	if err != nil {
+		return err
+	}
+	*l = x
+	return nil
+}
+
+// MarshalJSON 模版数据hex数组
+func (l TemplateList) MarshalJSON() ([]byte, error) {
+	list := make([]string, len(l))
+	for i, t := range l {
+		list[i] = t.Hex()
+	}
+	return json.Marshal(list)
+}
+
+// UnmarshalJSON 兼容模版数据hex数组和使用,分隔的字符串
+func (l *TemplateList) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		return l.UnmarshalText([]byte(s))
+	}
+	var list []Template
+	if err := json.Unmarshal(b, &list); err != nil {
+		return errors.Wrap(err, "template list should be string or array")
+	}
+	*l = list
+	return nil
+}
+
+// bytes 签名数据中的模版部分: 各模版数据(不含类型)依次拼接
+func (l TemplateList) bytes() []byte {
+	var b []byte
+	for _, t := range l {
+		b = append(b, t.Data...)
+	}
+	return b
+}
+
+// ContainsMultisig 是否包含多签模版
+func (l TemplateList) ContainsMultisig() bool {
+	_, ok := l.multisig()
+	return ok
+}
+
+// multisig 多签模版, 有多个时使用最后一个(与 SignWithPrivateKey 原有的逻辑一致)
+func (l TemplateList) multisig() (Template, bool) {
+	for i := len(l) - 1; i >= 0; i-- {
+		if l[i].Type == TemplateTypeMultisig {
+			return l[i], true
+		}
+	}
+	return Template{}, false
+}
+
+// Validate 检查模版列表的顺序:
+// - 模版类型已知且不重复
+// - 多签模版最多一个且在最后(多签由私钥直接签名)
+// - 模版的owner(如delegate的owner)为模版地址时, 下一项须为owner的模版数据,
+// 第一项为投票模版时可以是转账地址(投票)的模版数据, 此时不要求下一项为其owner
+func (l TemplateList) Validate() error {
+	seen := map[string]bool{}
+	for i, t := range l {
+		if t.Type <= TemplateTypeMin || t.Type > templateDexorder {
+			return fmt.Errorf("template[%d]: unknown template type %d", i, t.Type)
+		}
+		addr := t.Address()
+		if seen[addr] {
+			return fmt.Errorf("template[%d]: duplicated template %s", i, addr)
+		}
+		seen[addr] = true
+		if t.Type == TemplateTypeMultisig && i != len(l)-1 {
+			return fmt.Errorf("template[%d]: multisig template should be the last one", i)
+		}
+		owner, ok, err := templateOwner(t.Hex())
+		if err != nil {
+			return fmt.Errorf("template[%d]: %v", i, err)
+		}
+		if !ok || !owner.IsTemplate() || (i == 0 && t.Type == TemplateTypeVote) {
+			continue
+		}
+		if i == len(l)-1 {
+			return fmt.Errorf("template[%d]: owner template %s of %s missing", i, owner, t.Type)
+		}
+		if next := l[i+1].Address(); next != owner.String() {
+			return fmt.Errorf("template[%d]: expect owner template %s, got %s", i+1, owner, next)
+		}
+	}
+	return nil
+}
+
+// SignWithTemplates 与 SignWithPrivateKey 相同, 模版数据使用 TemplateList
+func (rtx *RawTransaction) SignWithTemplates(serializer Serializer, templates TemplateList, privkHex string) error {
+	return rtx.SignWithPrivateKey(serializer, templates.String(), privkHex)
+}
+
+// Templates 解析 TplHex
+func (data *TXData) Templates() (TemplateList, error) {
+	return ParseTemplateList(data.TplHex)
+}
+
+// SetTemplates 设置 TplHex
+func (data *TXData) SetTemplates(l TemplateList) {
+	data.TplHex = l.String()
+}
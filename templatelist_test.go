@@ -0,0 +1,100 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTemplateList(t *testing.T) {
+	w := TW{T: t}
+	var keys []AddrKeyPair
+	for i := 0; i < 4; i++ {
+		k, err := MakeKeyPair()
+		w.Nil(err)
+		keys = append(keys, k)
+	}
+	multisigTpl := testMultisigTplHex(t, 2, keys[0].Pubk, keys[1].Pubk)
+	multisigAddr, err := TemplateAddress(multisigTpl)
+	w.Nil(err)
+	multisigDest, err := NewCDestinationFromAddress(multisigAddr)
+	w.Nil(err)
+	delegateAddr, delegateTpl, err := CreateTemplateDataDelegate(keys[2].Pubk, multisigDest)
+	w.Nil(err)
+	delegateDest, err := NewCDestinationFromAddress(delegateAddr)
+	w.Nil(err)
+	voterDest, err := NewCDestinationFromAddress(keys[3].Addr)
+	w.Nil(err)
+	_, voteTpl, err := CreateTemplateDataVote(VoteTpl{Delegate: delegateDest, Voter: voterDest})
+	w.Nil(err)
+
+	s := strings.Join([]string{voteTpl, delegateTpl, multisigTpl}, ",")
+	l, err := ParseTemplateList(s)
+	w.Nil(err).Equal(3, len(l)).Equal(s, l.String())
+	w.Equal(TemplateTypeVote, l[0].Type).Equal(TemplateTypeMultisig, l[2].Type)
+	w.Equal(delegateAddr, l[1].Address()).True(l.ContainsMultisig())
+	w.Nil(l.Validate())
+
+	b, err := json.Marshal(l)
+	w.Nil(err).Equal(`["`+voteTpl+`","`+delegateTpl+`","`+multisigTpl+`"]`, string(b))
+	var got TemplateList
+	w.Nil(json.Unmarshal(b, &got)).Equal(l, got)
+	w.Nil(json.Unmarshal([]byte(`"`+s+`"`), &got)).Equal(l, got)
+	text, err := l.MarshalText()
+	w.Nil(err).Equal(s, string(text))
+
+	empty, err := ParseTemplateList("")
+	w.Nil(err).Equal(0, len(empty)).Equal("", empty.String()).True(!empty.ContainsMultisig())
+	w.Nil(empty.Validate())
+	_, err = ParseTemplateList("00")
+	w.True(err != nil, "too short")
+	_, err = ParseTemplateList("zz00")
+	w.True(err != nil, "hex")
+
+	for _, tt := range []struct {
+		name string
+		l    []string
+	}{
+		{"multisig not last", []string{multisigTpl, delegateTpl}},
+		{"owner missing", []string{delegateTpl}},
+		{"owner order", []string{voteTpl, multisigTpl, delegateTpl}},
+		{"duplicated", []string{delegateTpl, multisigTpl, multisigTpl}},
+		{"unknown type", []string{"ff00" + strings.Repeat("00", 32)}},
+	} {
+		l, err := ParseTemplateList(strings.Join(tt.l, ","))
+		w.Nil(err, tt.name)
+		w.True(l.Validate() != nil, tt.name)
+	}
+
+	// 多签模版类型判断不应只看hex前缀
+	data := TXData{TplHex: "0201" + strings.Repeat("00", 32)}
+	w.True(!data.ContainsMultisig())
+	data.SetTemplates(l[1:])
+	w.True(data.ContainsMultisig())
+	data.TplHex += ",zz"
+	w.True(data.ContainsMultisig(), "malformed entry")
+	data.SetTemplates(l[1:])
+	tpls, err := data.Templates()
+	w.Nil(err).Equal(l[1:], tpls)
+
+	rtx, err := NewTXBuilder().
+		SetAnchor(BBCMainnet.GenesisAnchor).
+		SetTimestamp(1590474715).
+		AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 0).
+		SetAddress(keys[3].Addr).
+		SetAmount(1).SetFee(0.01).
+		Build()
+	w.Nil(err)
+	expected := *rtx
+	w.Nil(rtx.SignWithTemplates(BBCSerializer, l[1:], keys[0].Privk))
+	w.Nil(expected.SignWithPrivateKey(BBCSerializer, delegateTpl+","+multisigTpl, keys[0].Privk))
+	w.Equal(expected.SignBytes, rtx.SignBytes)
+
+	// 多个多签模版时使用最后一个签名
+	other := testMultisigTplHex(t, 1, keys[2].Pubk, keys[3].Pubk)
+	twoMultisig := *rtx
+	twoMultisig.SignBytes, twoMultisig.SizeSign = nil, 0
+	w.Nil(twoMultisig.SignWithPrivateKey(BBCSerializer, multisigTpl+","+other, keys[2].Privk))
+	twoMultisig.SignBytes, twoMultisig.SizeSign = nil, 0
+	w.True(twoMultisig.SignWithPrivateKey(BBCSerializer, other+","+multisigTpl, keys[2].Privk) != nil, "key not in last multisig")
+}
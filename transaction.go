@@ -6,7 +6,6 @@ import (
 	"encoding/binary"
 	"encoding/hex"
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,7 +14,7 @@ import (
 	"golang.org/x/crypto/blake2b"
 )
 
-// DecodeRawTransaction hexed tx parse
+// DecodeRawTransaction hexed tx parse, serializer 可以直接使用 *ChainParams 或 ChainParams
 func DecodeRawTransaction(serializer Serializer, txData string, decodeSignData bool) (*Transaction, error) {
 	b, err := hex.DecodeString(txData)
 	if err != nil {
@@ -30,14 +29,18 @@ func DecodeRawTransaction(serializer Serializer, txData string, decodeSignData b
 		rtx.SignBytes = []byte{}
 	}
 	tx := rtx.ToTransaction(decodeSignData)
+	tx.ForkName = forkName(serializer, tx.HashAnchor)
 	return &tx, nil
 }
 
 // ToTransaction .
 func (rtx RawTransaction) ToTransaction(includeSignData bool) Transaction {
-	tx := Transaction{RawTransaction: rtx}
+	tx := Transaction{RawTransaction: rtx, TypeName: rtx.TxType().String()}
 	tx.HashAnchor = hex.EncodeToString(CopyReverse(tx.HashAnchorBytes[:]))
-	tx.Address, _ = EncodeAddress(tx.Prefix, hex.EncodeToString(CopyReverse(tx.AddressBytes[:])))
+	if f, ok := DefaultForkRegistry.Lookup(tx.HashAnchor); ok {
+		tx.ForkName = f.Name
+	}
+	tx.Address = string(AppendAddress(make([]byte, 0, 57), tx.Prefix, tx.AddressBytes))
 	if includeSignData {
 		tx.Sign = hex.EncodeToString(tx.SignBytes)
 	}
@@ -72,6 +75,19 @@ func (rtx *RawTransaction) EncodeBytes(serializer Serializer, encodeSignData boo
 	return serializer.Serialize(tx)
 }
 
+// AppendSerialize 将序列化后的tx(包含签名数据)追加到dst,
+// 使用本包提供的Serializer时不产生额外的内存分配(dst容量足够时)
+func (rtx *RawTransaction) AppendSerialize(dst []byte, serializer Serializer) ([]byte, error) {
+	if s, err := streamConfig(serializer); err == nil {
+		return s.appendSerialize(dst, rtx), nil
+	}
+	b, err := serializer.Serialize(*rtx)
+	if err != nil {
+		return dst, err
+	}
+	return append(dst, b...), nil
+}
+
 // Txid serialize tx -> blake2bSum256 -> reverse(got x) -> replace [0:4] with timestamp(bigEndian) -> hex encode
 func (rtx *RawTransaction) Txid(serializer Serializer) (string, error) {
 	msg, err := serializer.Serialize(*rtx)
@@ -117,21 +133,14 @@ func (rtx *RawTransaction) TxHash(serializer Serializer) ([32]byte, error) {
 //
 // 注意：签名逻辑不对模版数据进行严格合理的校验，因为离线环境下无法感知模版数据的有效性，调用方需自行确保参数正确
 func (rtx *RawTransaction) SignWithPrivateKey(serializer Serializer, templateDataList, privkHex string) error {
-	var rawTemplateBytes []byte //移除每个模版的前2个byte（类型说明），并join
+	templates, err := ParseTemplateList(templateDataList)
+	if err != nil {
+		return err
+	}
+	rawTemplateBytes := templates.bytes() //移除每个模版的前2个byte（类型说明），并join
 	var multisigTemplateData string
-
-	for _, tpl := range strings.Split(templateDataList, TemplateDataSpliter) {
-		if len(tpl) == 0 {
-			continue
-		}
-		_b, err := hex.DecodeString(tpl)
-		if err != nil {
-			return fmt.Errorf("unable to decode template data: %v", err)
-		}
-		rawTemplateBytes = append(rawTemplateBytes, _b[2:]...) //前2位为模版类型
-		if GetTemplateType(tpl) == TemplateTypeMultisig {
-			multisigTemplateData = tpl
-		}
+	if tpl, ok := templates.multisig(); ok {
+		multisigTemplateData = tpl.Hex()
 	}
 
 	if multisigTemplateData == "" && len(rtx.SignBytes) > 0 { //非多签确已经有签名数据了
@@ -192,20 +201,44 @@ func (rtx *RawTransaction) SignWithPrivateKey(serializer Serializer, templateDat
 
 // TXBuilder .
 type TXBuilder struct {
-	rtx *RawTransaction
-	err error
+	rtx    *RawTransaction
+	params *ChainParams
+	err    error
+
+	// 与链参数有关的设置在 Build 时按最终的链参数计算, 与调用顺序无关
+	amount     *decimal.Decimal //SetAmount 设置的金额(币)
+	fee        *decimal.Decimal //SetFee 设置的手续费(币)
+	minFee     bool             //SetMinFee
+	versionSet bool             //SetVersion 优先于链参数的默认版本
 }
 
 func NewTXBuilder() *TXBuilder {
 	return &TXBuilder{
 		rtx: &RawTransaction{
 			Version: 1,
-			Typ:     0, //token
-
+			Typ:     uint16(TxTypeToken),
 		},
 	}
 }
 
+// SetChainParams 指定链参数, 可以在任意位置调用, Build 时:
+// 未调用 SetVersion 则使用该链默认的tx版本, SetAmount/SetFee 的金额使用链参数的精度,
+// 并检查anchor属于该链、地址规则、手续费不低于链参数的最低手续费
+func (b *TXBuilder) SetChainParams(p *ChainParams) *TXBuilder {
+	if p == nil {
+		b.SetErr(errors.New("nil chain params"))
+		return b
+	}
+	b.params = p
+	return b
+}
+
+// SetMinFee Build 时使用链参数根据最终的data计算最低手续费, 需要设置链参数; 铸币tx没有手续费, 不设置
+func (b *TXBuilder) SetMinFee() *TXBuilder {
+	b.minFee, b.fee = true, nil
+	return b
+}
+
 // return b.err != nil
 func (b *TXBuilder) SetErr(e error) {
 	if b.err == nil {
@@ -245,12 +278,26 @@ func (b *TXBuilder) SetLockUntil(lockUntil int) *TXBuilder {
 // SetVersion 当前版本 1
 func (b *TXBuilder) SetVersion(v int) *TXBuilder {
 	b.rtx.Version = uint16(v)
+	b.versionSet = true
 	return b
 }
 
-// SetType tx type
+// SetType tx type, 参考 TxType
 func (b *TXBuilder) SetType(v int) *TXBuilder {
-	b.rtx.Typ = uint16(v)
+	if v < 0 || v > 0xffff || !TxType(v).IsValid() {
+		b.SetErr(fmt.Errorf("unknown tx type %d", v))
+		return b
+	}
+	return b.SetTxType(TxType(v))
+}
+
+// SetTxType tx type
+func (b *TXBuilder) SetTxType(typ TxType) *TXBuilder {
+	if !typ.IsValid() {
+		b.SetErr(fmt.Errorf("unknown tx type %d", typ))
+		return b
+	}
+	b.rtx.Typ = uint16(typ)
 	return b
 }
 
@@ -295,17 +342,27 @@ func (b *TXBuilder) SetAmount(amount float64) *TXBuilder {
 		b.SetErr(fmt.Errorf("amount should be greater than 0"))
 		return b
 	}
-	b.rtx.Amount = decimal.NewFromFloat(amount).Mul(decimal.NewFromInt(Precision)).IntPart()
+	d := decimal.NewFromFloat(amount)
+	b.amount = &d
+	b.rtx.Amount = b.params.FromCoin(d)
 	return b
 }
 
-// SetFee 手续费，目前0.01，如果带data则0.03, 额外需咨询BBC
+// setAmountRaw 以最小单位设置金额, 不随链参数的精度变化(如模版中记录的金额)
+func (b *TXBuilder) setAmountRaw(amount int64) *TXBuilder {
+	b.amount, b.rtx.Amount = nil, amount
+	return b
+}
+
+// SetFee 手续费，目前0.01，如果带data则0.03, 设置了链参数时可以使用 SetMinFee 自动计算
 func (b *TXBuilder) SetFee(fee float64) *TXBuilder {
 	if fee < 0 {
 		b.SetErr(fmt.Errorf("amount should be greater than 0"))
 		return b
 	}
-	b.rtx.TxFee = decimal.NewFromFloat(fee).Mul(decimal.NewFromInt(Precision)).IntPart()
+	d := decimal.NewFromFloat(fee)
+	b.fee, b.minFee = &d, false
+	b.rtx.TxFee = b.params.FromCoin(d)
 	return b
 }
 
@@ -342,32 +399,74 @@ func (b *TXBuilder) SetData(dataFmt string, data []byte) *TXBuilder {
 	return b.SetRawData(vd.Bytes())
 }
 
-// Build .
+// Build 按照tx类型检查:
+// - token: 需要输入、金额、手续费
+// - certification: 需要手续费、data(注册数据), 可以没有输入, 金额可以为0
+// - genesis/stake/work: 不能有输入和手续费, 需要金额
 func (b *TXBuilder) Build() (*RawTransaction, error) {
-	if b.rtx.SizeIn == 0 {
-		return nil, errors.New("no input provided")
+	if b.err != nil {
+		return nil, b.err
+	}
+	if err := b.applyChainParams(); err != nil {
+		return nil, err
+	}
+	typ := b.rtx.TxType()
+	if typ.IsMint() {
+		if b.rtx.SizeIn != 0 {
+			return nil, fmt.Errorf("%s tx should not have inputs", typ)
+		}
+		if b.rtx.TxFee != 0 {
+			return nil, fmt.Errorf("%s tx should not have tx fee", typ)
+		}
+	} else {
+		if b.rtx.SizeIn == 0 && typ != TxTypeCert {
+			return nil, errors.New("no input provided")
+		}
+		if b.rtx.TxFee == 0 {
+			return nil, errors.New("tx fee not set")
+		}
 	}
-	if b.rtx.Amount == 0 {
+	if typ == TxTypeCert {
+		if len(b.rtx.VchData) == 0 {
+			return nil, errors.New("certification tx requires enroll data")
+		}
+	} else if b.rtx.Amount == 0 {
 		return nil, errors.New("amount not set")
 	}
-	if b.rtx.TxFee == 0 {
-		return nil, errors.New("tx fee not set")
+
+	if b.params != nil { //不使用anchor的链(MKF)不检查forkID
+		if err := b.params.ValidateAnchor(b.rtx.HashAnchorBytes); err != nil {
+			return nil, err
+		}
+		if err := b.params.Address.ValidateDestination(b.rtx.Prefix, b.rtx.AddressBytes); err != nil {
+			return nil, errors.Wrapf(err, "invalid address for chain %s", b.params.Name)
+		}
+		if minFee := b.params.EstimateFee(b.rtx); !typ.IsMint() && b.rtx.TxFee < minFee {
+			return nil, fmt.Errorf("tx fee %d less than min fee %d of chain %s", b.rtx.TxFee, minFee, b.params.Name)
+		}
 	}
+	return b.rtx, nil
+}
 
-	{ //不再检查forkID, MKF不需要这个
-		// noZeroFound := true
-		// for i := 0; i < 32; i++ {
-		// 	if b.rtx.HashAnchorBytes[i] != 0 {
-		// 		noZeroFound = false
-		// 		break
-		// 	}
-		// }
-		// if noZeroFound {
-		// 	return nil, errors.New("fork id not provided")
-		// }
+// applyChainParams 按最终的链参数设置版本、金额和手续费
+func (b *TXBuilder) applyChainParams() error {
+	if b.minFee && !b.rtx.TxType().IsMint() {
+		if b.params == nil {
+			return errors.New("chain params not set")
+		}
+		b.rtx.TxFee = b.params.EstimateFee(b.rtx)
 	}
-	if b.err != nil {
-		return nil, b.err
+	if b.params == nil {
+		return nil
 	}
-	return b.rtx, nil
+	if !b.versionSet {
+		b.rtx.Version = b.params.DefaultTxVersion
+	}
+	if b.amount != nil {
+		b.rtx.Amount = b.params.FromCoin(*b.amount)
+	}
+	if b.fee != nil {
+		b.rtx.TxFee = b.params.FromCoin(*b.fee)
+	}
+	return nil
 }
@@ -162,3 +162,53 @@ func TestEncodeTX(t *testing.T) {
 	tx := rtx.ToTransaction(true)
 	fmt.Println(JSONIndent(tx))
 }
+
+func TestTXBuilderTxType(t *testing.T) {
+	w := TW{T: t}
+	w.Equal("certification", TxTypeCert.String()).
+		Equal("unknown", TxType(7).String()).
+		True(TxTypeStake.IsMint()).
+		True(!TxTypeCert.IsMint())
+
+	base := func(typ TxType) *TXBuilder {
+		return NewTXBuilder().
+			SetTxType(typ).
+			SetAnchor("00000000b0a9be545f022309e148894d1e1c853ccac3ef04cb6f5e5c70f41a70").
+			SetTimestamp(1590474715).
+			SetAddress("1fhtnq5n1b9bte99x5fw0m7cw9jm4n6kgv9nbeynscsgzryvhjf7ny9tm")
+	}
+	input := func(b *TXBuilder) *TXBuilder {
+		return b.AddInput("5ec5e3989f7c93addc642d0a3fb6cd911b22a3017ebd971894327080aea2e782", 1)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		builder *TXBuilder
+		wantErr bool
+	}{
+		{"token", input(base(TxTypeToken)).SetAmount(1).SetFee(0.01), false},
+		{"token zero amount", input(base(TxTypeToken)).SetFee(0.01), true},
+		{"cert zero amount", input(base(TxTypeCert)).SetRawData([]byte{1}).SetFee(0.01), false},
+		{"cert no data", input(base(TxTypeCert)).SetFee(0.01), true},
+		{"cert no input", base(TxTypeCert).SetRawData([]byte{1}).SetFee(0.01), false},
+		{"stake", base(TxTypeStake).SetAmount(15).SetChainParams(BBCMainnet), false},
+		{"stake min fee", base(TxTypeStake).SetAmount(15).SetChainParams(BBCMainnet).SetMinFee(), false},
+		{"stake with input", input(base(TxTypeStake)).SetAmount(15), true},
+		{"work with fee", base(TxTypeWork).SetAmount(15).SetFee(0.01), true},
+		{"unknown type", input(base(TxTypeToken)).SetType(7).SetAmount(1).SetFee(0.01), true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			w := TW{T: t}
+			rtx, err := tt.builder.Build()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Build() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil {
+				hexed, err := rtx.Encode(BBCSerializer, false)
+				w.Nil(err)
+				tx, err := DecodeRawTransaction(BBCSerializer, hexed, false)
+				w.Nil(err).Equal(rtx.TxType().String(), tx.TypeName)
+			}
+		})
+	}
+}
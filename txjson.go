@@ -0,0 +1,167 @@
+package gobbc
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+)
+
+// transactionJSON Transaction 的json格式, 字段名与默认json编码一致
+type transactionJSON struct {
+	Version    uint16
+	Typ        uint16
+	Timestamp  uint32
+	LockUntil  uint32
+	SizeIn     uint64
+	Prefix     uint8
+	Amount     int64
+	TxFee      int64
+	SizeOut    uint64
+	SizeSign   uint64
+	HashAnchor string
+	Address    string
+	From       string `json:",omitempty"`
+	Sign       string
+	Vin        []Vin
+	Data       string
+	ForkName   string
+	TypeName   string
+}
+
+// MarshalJSON .
+func (tx Transaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(transactionJSON{
+		Version:    tx.Version,
+		Typ:        tx.Typ,
+		Timestamp:  tx.Timestamp,
+		LockUntil:  tx.LockUntil,
+		SizeIn:     tx.SizeIn,
+		Prefix:     tx.Prefix,
+		Amount:     tx.Amount,
+		TxFee:      tx.TxFee,
+		SizeOut:    tx.SizeOut,
+		SizeSign:   tx.SizeSign,
+		HashAnchor: tx.HashAnchor,
+		Address:    tx.Address,
+		From:       tx.From,
+		Sign:       tx.Sign,
+		Vin:        tx.Vin,
+		Data:       tx.Data,
+		ForkName:   tx.ForkName,
+		TypeName:   tx.TypeName,
+	})
+}
+
+// UnmarshalJSON 解码并根据 HashAnchor, Address, Vin, Data, Sign 重建 RawTransaction 的二进制字段,
+// 与 Prefix, SizeIn, SizeOut, SizeSign 不一致时返回错误.
+// ToTransaction(false) 的结果不含签名数据, SizeSign 不为0时无法还原, 返回错误
+func (tx *Transaction) UnmarshalJSON(b []byte) error {
+	var j transactionJSON
+	if err := json.Unmarshal(b, &j); err != nil {
+		return err
+	}
+	x := Transaction{
+		RawTransaction: RawTransaction{
+			Version:   j.Version,
+			Typ:       j.Typ,
+			Timestamp: j.Timestamp,
+			LockUntil: j.LockUntil,
+			SizeIn:    j.SizeIn,
+			Prefix:    j.Prefix,
+			Amount:    j.Amount,
+			TxFee:     j.TxFee,
+			SizeOut:   j.SizeOut,
+			SizeSign:  j.SizeSign,
+		},
+		HashAnchor: j.HashAnchor,
+		Address:    j.Address,
+		From:       j.From,
+		Sign:       j.Sign,
+		Vin:        j.Vin,
+		Data:       j.Data,
+		ForkName:   j.ForkName,
+		TypeName:   j.TypeName,
+	}
+	rtx, err := x.rawTransaction()
+	if err != nil {
+		return err
+	}
+	x.RawTransaction = rtx
+	*tx = x
+	return nil
+}
+
+// Validate 检查 RawTransaction 的二进制字段与 HashAnchor, Address, Vin, Data, Sign 以及 Prefix, SizeIn, SizeOut, SizeSign 一致, 不修改 tx
+func (tx *Transaction) Validate() error {
+	rtx, err := tx.rawTransaction()
+	if err != nil {
+		return err
+	}
+	switch {
+	case rtx.HashAnchorBytes != tx.HashAnchorBytes:
+		return errors.New("HashAnchor does not match HashAnchorBytes")
+	case rtx.AddressBytes != tx.AddressBytes:
+		return errors.New("Address does not match AddressBytes")
+	case !bytes.Equal(rtx.Input, tx.Input):
+		return errors.New("Vin does not match Input")
+	case !bytes.Equal(rtx.VchData, tx.VchData):
+		return errors.New("Data does not match VchData")
+	case !bytes.Equal(rtx.SignBytes, tx.SignBytes):
+		return errors.New("Sign does not match SignBytes")
+	}
+	return nil
+}
+
+// rawTransaction 根据 HashAnchor, Address, Vin, Data, Sign 重建二进制字段, 并校验与 Prefix, SizeIn, SizeOut, SizeSign 一致
+func (tx *Transaction) rawTransaction() (RawTransaction, error) {
+	rtx := tx.RawTransaction
+	rtx.HashAnchorBytes = [32]byte{}
+	if tx.HashAnchor != "" {
+		anchor, err := decodeHash(tx.HashAnchor)
+		if err != nil {
+			return rtx, errors.Wrap(err, "invalid HashAnchor")
+		}
+		rtx.HashAnchorBytes = anchor
+	}
+
+	dest, err := NewCDestinationFromAddress(tx.Address)
+	if err != nil {
+		return rtx, errors.Wrap(err, "invalid Address")
+	}
+	if dest.Prefix != rtx.Prefix {
+		return rtx, fmt.Errorf("Prefix %d does not match Address %s", rtx.Prefix, tx.Address)
+	}
+	rtx.AddressBytes = dest.Data
+
+	if uint64(len(tx.Vin)) != rtx.SizeIn {
+		return rtx, fmt.Errorf("SizeIn %d does not match Vin count %d", rtx.SizeIn, len(tx.Vin))
+	}
+	rtx.Input = make([]byte, 0, len(tx.Vin)*33)
+	for i, vin := range tx.Vin {
+		txid, err := decodeHash(vin.Txid)
+		if err != nil {
+			return rtx, errors.Wrapf(err, "invalid Vin[%d].Txid", i)
+		}
+		if vin.Vout < 0 || vin.Vout > 0xff {
+			return rtx, fmt.Errorf("invalid Vin[%d].Vout %d", i, vin.Vout)
+		}
+		rtx.Input = append(append(rtx.Input, txid[:]...), uint8(vin.Vout))
+	}
+
+	if rtx.VchData, err = hex.DecodeString(tx.Data); err != nil {
+		return rtx, errors.Wrap(err, "invalid Data")
+	}
+	if uint64(len(rtx.VchData)) != rtx.SizeOut {
+		return rtx, fmt.Errorf("SizeOut %d does not match Data length %d", rtx.SizeOut, len(rtx.VchData))
+	}
+	if rtx.SignBytes, err = hex.DecodeString(tx.Sign); err != nil {
+		return rtx, errors.Wrap(err, "invalid Sign")
+	}
+	if uint64(len(rtx.SignBytes)) != rtx.SizeSign {
+		return rtx, fmt.Errorf("SizeSign %d does not match Sign length %d", rtx.SizeSign, len(rtx.SignBytes))
+	}
+	return rtx, nil
+}
@@ -0,0 +1,67 @@
+package gobbc
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestTransactionJSON(t *testing.T) {
+	w := TW{T: t}
+	const data = "010000004aeaed5d00000000701af4705c5e6fcb04efc3ca3c851c1e4d8948e10923025f54bea9b00000000002799a49bcd8ca8723aa00aad86cec19d4d095191c20ce44000cfa7f6b09e9ed5d002b8336b3f242db6ecdc939c168f9613b14f0f4fd00418c3c9ba849c14aeaed5d0101f30b1fd894ba3eacf1b2309ce9fcb606892a70604af5791a732df423e47f001d9c64cd1d000000006400000000000000008164f1a77bd0e00f8023ffa2f7e0a76eb795414d9a57eb2f4ce5e9cc730c8103c501e1cbd24fa95312b81d2dc5ef6f60c39a9485819d4fa11bcfdde5f99151c8a4f981e14068ae196ce63ef403bc335ff439a00d1f00cc3e45cfc057354ea408cafad8e1fb769de3672a155545d490813e1c6eeefb7b4dec678e669c5de7e3c20b07"
+	tx, err := DecodeRawTransaction(BBCSerializer, data, true)
+	w.Nil(err)
+
+	b, err := json.Marshal(tx)
+	w.Nil(err)
+	for _, field := range []string{`"Version":1`, `"SizeIn":2`, `"HashAnchor":"00000000b0a9`, `"Vin":[{"Txid":"5dede909`, `"Sign":"64f1a77b`, `"TypeName":"token"`} {
+		w.True(strings.Contains(string(b), field), field, string(b))
+	}
+	w.True(!strings.Contains(string(b), `"From"`), "From omitted when empty")
+
+	var got Transaction
+	w.Nil(json.Unmarshal(b, &got)).Equal(*tx, got).Nil(got.Validate())
+	enc, err := got.RawTransaction.Encode(BBCSerializer, true)
+	w.Nil(err).Equal(data, enc)
+
+	tx.From = "1yc5hzp4mq8zaswdj62eekz5p0t4jmw309btqj6kk5qt27s3z00embbrg"
+	b, err = json.Marshal(tx)
+	w.Nil(err)
+	w.Nil(json.Unmarshal(b, &got)).Equal(tx.From, got.From)
+
+	// Validate 不修改tx
+	modified := got
+	modified.Data = "00"
+	w.True(modified.Validate() != nil).Equal("00", modified.Data).Equal(got.VchData, modified.VchData)
+	modified = got
+	modified.Input = nil
+	w.True(modified.Validate() != nil, "input").Equal(0, len(modified.Input))
+	w.True((&Transaction{}).Validate() != nil, "empty")
+
+	// 不含签名数据
+	unsigned := tx.RawTransaction
+	unsigned.SignBytes, unsigned.SizeSign = nil, 0
+	b, err = json.Marshal(unsigned.ToTransaction(false))
+	w.Nil(err)
+	w.Nil(json.Unmarshal(b, &got)).Nil(got.Validate()).Equal(0, len(got.SignBytes))
+	// SizeSign 不为0但不含签名数据的json无法还原
+	b, err = json.Marshal(tx.RawTransaction.ToTransaction(false))
+	w.Nil(err)
+	w.True(json.Unmarshal(b, &got) != nil, "sign data omitted")
+	w.True(json.Unmarshal([]byte(`{"Version":1,"Address":""}`), &got) != nil, "empty address")
+
+	for _, tt := range []struct{ name, old, new string }{
+		{"size in", `"SizeIn":2`, `"SizeIn":3`},
+		{"prefix", `"Prefix":1`, `"Prefix":2`},
+		{"anchor", `"HashAnchor":"00000000b0a9`, `"HashAnchor":"00b0a9`},
+		{"vout", `"Vout":1`, `"Vout":256`},
+		{"data", `"Data":""`, `"Data":"00"`},
+		{"address", `"Address":"1yc5`, `"Address":"1yc`},
+	} {
+		b, err := json.Marshal(tx)
+		w.Nil(err)
+		s := strings.Replace(string(b), tt.old, tt.new, 1)
+		w.True(s != string(b), tt.name)
+		w.True(json.Unmarshal([]byte(s), &got) != nil, tt.name)
+	}
+}
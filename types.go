@@ -31,10 +31,17 @@ type Transaction struct {
 	RawTransaction
 	HashAnchor string // hex string([65]byte)
 	Address    string // hex string ([64 + 1]byte)
+	From       string // 转出地址, 交易数据不包含, 参考 ResolveFrom
 	Sign       string // hex string
 	Vin        []Vin
 	Data       string
+	ForkName   string // HashAnchor 对应的已知分支名称，未知时为空
+	TypeName   string // Typ 对应的类型名称, 参考 TxType
 }
+
+// TxType .
+func (rtx RawTransaction) TxType() TxType { return TxType(rtx.Typ) }
+
 type Vin struct {
 	Txid string
 	Vout int
@@ -46,13 +53,10 @@ type TXData struct {
 	TxHex  string `json:"tx_hex,omitempty"`  //encoded tx data
 }
 
-// ContainsMultisig .
+// ContainsMultisig 模版数据中是否包含多签模版, 逐项按模版类型判断, 无法解析的项忽略
 func (data *TXData) ContainsMultisig() bool {
 	for _, tpl := range strings.Split(data.TplHex, TemplateDataSpliter) {
-		if len(tpl) == 0 {
-			continue
-		}
-		if strings.HasPrefix(tpl, TemplateTypeMultisigPrefix) {
+		if typ, _, err := decodeTemplateHex(tpl); err == nil && typ == TemplateTypeMultisig {
 			return true
 		}
 	}
@@ -64,9 +68,21 @@ func (data *TXData) EncodeString() (string, error) {
 	return fmt.Sprintf("enc;%s;%s", data.TplHex, data.TxHex), nil
 }
 
-// DecodeString parse jsonHex set value to data
+// DecodeString parse jsonHex set value to data, 同时支持 TXEnvelope 的文本格式(参考 DecodeTXEnvelope)
 func (data *TXData) DecodeString(jsonHex string) error {
-	if !strings.HasPrefix(jsonHex, "enc;") {
+	if !strings.HasPrefix(jsonHex, txEnvelopeLegacyPrefix) {
+		e, err := DecodeTXEnvelope(jsonHex)
+		if err != nil {
+			return fmt.Errorf("Tx data not has prefix: enc, %v", err)
+		}
+		*data = e.TXData()
+		return nil
+	}
+	return data.decodeLegacy(jsonHex)
+}
+
+func (data *TXData) decodeLegacy(jsonHex string) error {
+	if !strings.HasPrefix(jsonHex, txEnvelopeLegacyPrefix) {
 		return errors.New("Tx data not has prefix: enc")
 	}
 	arr := strings.Split(jsonHex, ";")